@@ -0,0 +1,182 @@
+package errx
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// StackMode - способ сбора стека при создании ошибки
+type StackMode byte
+
+// Способы сбора стека
+const (
+	StackUnknown StackMode = iota // Не известен: слой без стека или упакован без способа, в политике - как StackFull
+	StackFull                     // Весь стек, не больше StackPolicy.Depth кадров
+	StackNone                     // Без стека
+	StackCaller                   // Только место вызова
+	StackSampled                  // Весь стек для доли StackPolicy.Rate вызовов, для остальных место вызова
+)
+
+var stackModeNames = map[StackMode]string{
+	StackUnknown: "unknown",
+	StackFull:    "full",
+	StackNone:    "none",
+	StackCaller:  "caller",
+	StackSampled: "sampled",
+}
+
+func (m StackMode) String() string { return stackModeNames[m] }
+
+// StackPolicy - политика сбора стека
+type StackPolicy struct {
+	Mode  StackMode
+	Depth int     // Максимум кадров для полного стека, 0 - без ограничения
+	Rate  float64 // Доля вызовов с полным стеком для StackSampled, от 0 до 1
+}
+
+// StackOptions - что попадает в стек при создании ошибки
+type StackOptions struct {
+	Policy      StackPolicy // Политика по умолчанию, начальное значение берется из переменной ERRX_STACK
+	KeepRuntime bool        // Оставлять кадры пакета runtime (goexit, main, gopanic), по умолчанию они отбрасываются
+	Raw         bool        // Хранить адреса без символизации, Pack передает их вместо кадров (RawStack)
+}
+
+// StackEnv - переменная окружения с политикой по умолчанию в формате ParseStackPolicy
+const StackEnv = "ERRX_STACK"
+
+var stackOptions atomic.Value
+
+var stackPolicies = struct {
+	sync.Mutex
+	byErr atomic.Value // map[*v1Error]StackPolicy
+}{}
+
+func init() {
+	opts := StackOptions{}
+
+	if env := os.Getenv(StackEnv); env != "" {
+		if p, err := ParseStackPolicy(env); err != nil {
+			log.Printf("errx: %s: %v", StackEnv, err)
+		} else {
+			opts.Policy = p
+		}
+	}
+
+	stackOptions.Store(opts)
+	stackPolicies.byErr.Store(map[*v1Error]StackPolicy{})
+}
+
+// SetStackOptions - настройка сбора стека для всего процесса
+func SetStackOptions(opts StackOptions) { stackOptions.Store(opts) }
+
+// SetStackPolicy - политика сбора стека для ошибки и всех ее наследников через Derive,
+// важнее политики процесса. Обычно вызывается для шаблонов при инициализации пакета.
+func SetStackPolicy(err Error, p StackPolicy) {
+	e, ok := err.(*v1Error)
+	if !ok {
+		return
+	}
+
+	stackPolicies.Lock()
+	defer stackPolicies.Unlock()
+
+	old := stackPolicies.byErr.Load().(map[*v1Error]StackPolicy)
+	list := make(map[*v1Error]StackPolicy, len(old)+1)
+	for k, v := range old {
+		list[k] = v
+	}
+	list[e] = p
+	stackPolicies.byErr.Store(list)
+}
+
+// ParseStackPolicy - разбор политики из строки, как в переменной ERRX_STACK:
+// none, caller, full, full:N (не больше N кадров), sampled:R и sampled:R:N (полный стек для доли R вызовов).
+func ParseStackPolicy(s string) (StackPolicy, error) {
+	var p StackPolicy
+
+	args := strings.Split(strings.TrimSpace(s), ":")
+
+	switch args[0] {
+	case "none":
+		p.Mode = StackNone
+	case "caller":
+		p.Mode = StackCaller
+	case "full":
+		p.Mode = StackFull
+	case "sampled":
+		p.Mode = StackSampled
+
+		if len(args) < 2 {
+			return p, fmt.Errorf("sampled stack policy %q without rate", s)
+		}
+
+		rate, err := strconv.ParseFloat(args[1], 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return p, fmt.Errorf("invalid rate in stack policy %q", s)
+		}
+
+		p.Rate, args = rate, args[1:]
+	default:
+		return p, fmt.Errorf("unknown stack policy %q", s)
+	}
+
+	switch {
+	case len(args) == 2 && (p.Mode == StackFull || p.Mode == StackSampled):
+		depth, err := strconv.Atoi(args[1])
+		if err != nil || depth < 0 {
+			return p, fmt.Errorf("invalid depth in stack policy %q", s)
+		}
+		p.Depth = depth
+	case len(args) > 1:
+		return p, fmt.Errorf("invalid stack policy %q", s)
+	}
+
+	return p, nil
+}
+
+// capture - стек нового слоя по политике ближайшего прототипа или процесса, skip как в runtime.Caller.
+// Записывает способ, которым стек на самом деле собран, пустой стек не равен nil.
+func (e *v1Error) capture(err *v1Error, skip int) {
+	opts := stackOptions.Load().(StackOptions)
+	policy := opts.Policy
+
+	if list := stackPolicies.byErr.Load().(map[*v1Error]StackPolicy); len(list) > 0 {
+		for p := e; p != nil; p = p.proto {
+			if v, ok := list[p]; ok {
+				policy = v
+				break
+			}
+		}
+	}
+
+	if policy.Mode == StackUnknown {
+		policy.Mode = StackFull
+	}
+
+	limit := policy.Depth
+	err.mode = policy.Mode
+
+	switch policy.Mode {
+	case StackNone:
+		err.stack = []Frame{}
+		return
+	case StackCaller:
+		limit = 1
+	case StackSampled:
+		if rand.Float64() >= policy.Rate {
+			limit, err.mode = 1, StackCaller
+		}
+	}
+
+	if opts.Raw {
+		err.raw = rawStack(rawCallers(skip+1, limit), limit, opts.KeepRuntime)
+	} else {
+		err.stack = callers(skip+1, limit, opts.KeepRuntime)
+	}
+}
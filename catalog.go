@@ -0,0 +1,482 @@
+package errx
+
+import (
+	"encoding/json"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Catalog - сообщения для пользователя по локалям
+type Catalog struct {
+	mu    sync.RWMutex
+	msgs  map[string]map[string]message // Локаль -> ключ -> сообщение
+	rules map[string]PluralRule
+}
+
+type message struct {
+	forms map[string]string // Формы по категориям множественного числа и точным значениям "=N", у простого сообщения только "other"
+	param string            // Параметр, по которому выбирается форма
+}
+
+// pluralForms - ключи, из которых состоит таблица форм одного сообщения
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+// NewCatalog - пустой каталог
+func NewCatalog() *Catalog {
+	return &Catalog{
+		msgs:  make(map[string]map[string]message),
+		rules: make(map[string]PluralRule),
+	}
+}
+
+// Add - сообщения локали: строки или вложенные таблицы, ключи которых соединяются через точку.
+// Таблица из форм zero, one, two, few, many, other и точных значений "=N" - одно сообщение с множественным числом,
+// форма выбирается по параметру из ключа "param", по умолчанию "count". Форма other обязательна.
+// Параметры подставляются в текст вместо {name}.
+func (c *Catalog) Add(locale string, msgs map[string]interface{}) error {
+	flat := make(map[string]message)
+
+	if err := flatten(flat, "", msgs); err != nil {
+		return ErrBadRequest.WithReason(err).WithDetail("locale %s", locale)
+	}
+
+	locale = normLocale(locale)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.msgs[locale] == nil {
+		c.msgs[locale] = flat
+		return nil
+	}
+
+	for key := range flat {
+		c.msgs[locale][key] = flat[key]
+	}
+	return nil
+}
+
+// LoadJSON - сообщения локали из JSON
+func (c *Catalog) LoadJSON(locale string, data []byte) error {
+	var msgs map[string]interface{}
+
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return ErrBadRequest.WithReason(err).WithDetail("locale %s", locale)
+	}
+	return c.Add(locale, msgs)
+}
+
+// LoadTOML - сообщения локали из TOML.
+// Поддерживается подмножество, достаточное для каталогов: таблицы, ключи с точками и в кавычках, строки и комментарии.
+func (c *Catalog) LoadTOML(locale string, data []byte) error {
+	msgs, err := parseTOML(string(data))
+	if err != nil {
+		return ErrBadRequest.WithReason(err).WithDetail("locale %s", locale)
+	}
+	return c.Add(locale, msgs)
+}
+
+// LoadFS - файлы <locale>.json и <locale>.toml из каталога dir, например из embed.FS
+func (c *Catalog) LoadFS(fsys fs.FS, dir string) error {
+	list, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return ErrNotFound.WithReason(err)
+	}
+
+	for _, item := range list {
+		ext := path.Ext(item.Name())
+
+		if item.IsDir() || (ext != ".json" && ext != ".toml") {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, item.Name()))
+		if err != nil {
+			return ErrNotFound.WithReason(err)
+		}
+
+		locale := strings.TrimSuffix(item.Name(), ext)
+
+		if ext == ".json" {
+			err = c.LoadJSON(locale, data)
+		} else {
+			err = c.LoadTOML(locale, data)
+		}
+
+		if err != nil {
+			return ErrBadRequest.WithReason(err).WithDetail("file %s", item.Name())
+		}
+	}
+	return nil
+}
+
+// SetPluralRule - правило множественного числа для языка или локали вместо встроенного
+func (c *Catalog) SetPluralRule(locale string, rule PluralRule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules[normLocale(locale)] = rule
+}
+
+// Locales - локали, для которых есть сообщения, по алфавиту
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list := make([]string, 0, len(c.msgs))
+	for locale := range c.msgs {
+		list = append(list, locale)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Message - сообщение по ключу для первой локали из цепочки LocaleChain, в которой оно есть, и сама эта локаль
+func (c *Catalog) Message(key string, params map[string]string, locales ...string) (text, locale string, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, locale = range LocaleChain(locales...) {
+		msg, ok := c.msgs[locale][key]
+		if !ok {
+			continue
+		}
+
+		form := msg.forms["other"]
+
+		// Точное значение важнее категории, а недостающая категория заменяется формой other
+		if exact, ok := msg.forms["="+params[msg.param]]; ok {
+			form = exact
+		} else if cat, ok := msg.forms[c.plural(locale, params[msg.param])]; ok {
+			form = cat
+		}
+
+		return substitute(form, params), locale, true
+	}
+	return "", "", false
+}
+
+// plural - категория числа по правилу локали, если числа нет - other
+func (c *Catalog) plural(locale, num string) string {
+	i, v, ok := pluralOperands(num)
+	if !ok {
+		return "other"
+	}
+
+	for _, tag := range LocaleChain(locale) {
+		if rule, ok := c.rules[tag]; ok {
+			return rule(i, v)
+		}
+		if rule, ok := pluralRules[tag]; ok {
+			return rule(i, v)
+		}
+	}
+	return pluralOneOther(i, v)
+}
+
+// LocaleChain - локали в порядке поиска: каждая запрошенная и ее более общие варианты (pt-br, pt)
+func LocaleChain(locales ...string) []string {
+	var list []string
+	seen := make(map[string]bool)
+
+	for _, locale := range locales {
+		for tag := normLocale(locale); tag != ""; {
+			if !seen[tag] {
+				seen[tag] = true
+				list = append(list, tag)
+			}
+
+			i := strings.LastIndexByte(tag, '-')
+			if i < 0 {
+				break
+			}
+			tag = tag[:i]
+		}
+	}
+	return list
+}
+
+func normLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+func flatten(res map[string]message, prefix string, msgs map[string]interface{}) error {
+	for key, val := range msgs {
+		name := prefix + key
+
+		switch val := val.(type) {
+		case string:
+			res[name] = message{forms: map[string]string{"other": val}}
+
+		case map[string]interface{}:
+			if !isPlural(val) {
+				if err := flatten(res, name+".", val); err != nil {
+					return err
+				}
+				continue
+			}
+
+			msg := message{forms: make(map[string]string, len(val)), param: "count"}
+			for form, text := range val {
+				s, ok := text.(string)
+				if !ok {
+					return ErrBadRequest.WithDetail("%s.%s: expected string", name, form)
+				}
+
+				if form == "param" {
+					msg.param = s
+				} else {
+					msg.forms[form] = s
+				}
+			}
+
+			if _, ok := msg.forms["other"]; !ok {
+				return ErrBadRequest.WithDetail("%s: plural form \"other\" is required", name)
+			}
+			res[name] = msg
+
+		default:
+			return ErrBadRequest.WithDetail("%s: expected string or table, got %T", name, val)
+		}
+	}
+	return nil
+}
+
+// isPlural - таблица форм одного сообщения, а не вложенные ключи
+func isPlural(val map[string]interface{}) bool {
+	if len(val) == 0 {
+		return false
+	}
+
+	for key := range val {
+		if !pluralForms[key] && key != "param" && !strings.HasPrefix(key, "=") {
+			return false
+		}
+	}
+	return true
+}
+
+// substitute - подстановка параметров вместо {name}, неизвестные имена остаются как есть
+func substitute(text string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+
+	var buf strings.Builder
+
+	for {
+		i := strings.IndexByte(text, '{')
+		if i < 0 {
+			break
+		}
+
+		j := strings.IndexByte(text[i:], '}')
+		if j < 0 {
+			break
+		}
+
+		val, ok := params[text[i+1:i+j]]
+		if !ok {
+			buf.WriteString(text[:i+j+1])
+			text = text[i+j+1:]
+			continue
+		}
+
+		buf.WriteString(text[:i])
+		buf.WriteString(val)
+		text = text[i+j+1:]
+	}
+
+	buf.WriteString(text)
+	return buf.String()
+}
+
+// parseTOML - разбор подмножества TOML для каталогов сообщений
+func parseTOML(data string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	table := res
+
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.LastIndexByte(line, ']')
+			if end < 0 || !tomlTail(line[end+1:]) {
+				return nil, ErrBadRequest.WithDetail("line %d: expected \"[table]\"", n+1)
+			}
+
+			keys, err := tomlKey(line[1:end])
+			if err != nil {
+				return nil, ErrBadRequest.WithReason(err).WithDetail("line %d", n+1)
+			}
+
+			if table, err = tomlTable(res, keys); err != nil {
+				return nil, ErrBadRequest.WithReason(err).WithDetail("line %d", n+1)
+			}
+			continue
+		}
+
+		eq := tomlEquals(line)
+		if eq < 0 {
+			return nil, ErrBadRequest.WithDetail("line %d: expected \"key = value\"", n+1)
+		}
+
+		keys, err := tomlKey(line[:eq])
+		if err != nil {
+			return nil, ErrBadRequest.WithReason(err).WithDetail("line %d", n+1)
+		}
+
+		val, rest, err := tomlString(strings.TrimSpace(line[eq+1:]))
+		if err != nil || !tomlTail(rest) {
+			return nil, ErrBadRequest.WithReason(err).WithDetail("line %d: expected string value", n+1)
+		}
+
+		parent, err := tomlTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, ErrBadRequest.WithReason(err).WithDetail("line %d", n+1)
+		}
+
+		last := keys[len(keys)-1]
+		if _, ok := parent[last]; ok {
+			return nil, ErrBadRequest.WithDetail("line %d: duplicate key %q", n+1, last)
+		}
+		parent[last] = val
+	}
+
+	return res, nil
+}
+
+// tomlTable - вложенная таблица по ключам, недостающие создаются
+func tomlTable(root map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch next := root[key].(type) {
+		case nil:
+			table := make(map[string]interface{})
+			root[key], root = table, table
+		case map[string]interface{}:
+			root = next
+		default:
+			return nil, ErrBadRequest.WithDetail("key %q is not a table", key)
+		}
+	}
+	return root, nil
+}
+
+// tomlKey - части ключа: голые слова и строки в кавычках через точку
+func tomlKey(s string) ([]string, error) {
+	var keys []string
+
+	for {
+		s = strings.TrimSpace(s)
+
+		if s == "" {
+			return nil, ErrBadRequest.WithDetail("empty key")
+		}
+
+		var key string
+
+		if s[0] == '"' || s[0] == '\'' {
+			var err error
+			if key, s, err = tomlString(s); err != nil {
+				return nil, err
+			}
+		} else {
+			i := strings.IndexFunc(s, func(r rune) bool {
+				return !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if i < 0 {
+				i = len(s)
+			}
+			if i == 0 {
+				return nil, ErrBadRequest.WithDetail("unexpected %q in key", s[:1])
+			}
+			key, s = s[:i], s[i:]
+		}
+
+		keys = append(keys, key)
+
+		if s = strings.TrimSpace(s); s == "" {
+			return keys, nil
+		}
+
+		if s[0] != '.' {
+			return nil, ErrBadRequest.WithDetail("unexpected %q in key", s[:1])
+		}
+		s = s[1:]
+	}
+}
+
+// tomlEscapes - символы после "\", допустимые в строке TOML, остальные экранирования Go отвергаются
+const tomlEscapes = `btnfr"\uU`
+
+// tomlString - строка в двойных (с экранированием) или одинарных кавычках и остаток после нее
+func tomlString(s string) (val, rest string, err error) {
+	switch {
+	case strings.HasPrefix(s, `"""`) || strings.HasPrefix(s, "'''"):
+		return "", "", ErrNotImplemented.WithDetail("multi-line strings")
+
+	case strings.HasPrefix(s, `"`):
+		quoted, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", "", ErrBadRequest.WithReason(err)
+		}
+
+		for i := 1; i < len(quoted)-1; i++ {
+			if quoted[i] != '\\' {
+				continue
+			}
+
+			if i++; !strings.ContainsRune(tomlEscapes, rune(quoted[i])) {
+				return "", "", ErrBadRequest.WithDetail("invalid escape \\%c", quoted[i])
+			}
+		}
+		val, err := strconv.Unquote(quoted)
+		if err != nil {
+			return "", "", ErrBadRequest.WithReason(err)
+		}
+		return val, s[len(quoted):], nil
+
+	case strings.HasPrefix(s, "'"):
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", ErrBadRequest.WithDetail("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+
+	return "", "", ErrBadRequest.WithDetail("expected string")
+}
+
+// tomlEquals - позиция "=" вне кавычек ключа
+func tomlEquals(line string) int {
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case quote != 0:
+			if line[i] == '\\' && quote == '"' {
+				i++
+			} else if line[i] == quote {
+				quote = 0
+			}
+		case line[i] == '"' || line[i] == '\'':
+			quote = line[i]
+		case line[i] == '=':
+			return i
+		}
+	}
+	return -1
+}
+
+// tomlTail - после значения допустим только комментарий
+func tomlTail(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
@@ -0,0 +1,141 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/cli"
+	"github.com/stretchr/testify/suite"
+)
+
+var errConfig = errx.Register(errx.ErrBadRequest.Derive("bad config"))
+
+func TestCLI(t *testing.T) {
+	suite.Run(t, new(CLISuite))
+}
+
+type CLISuite struct {
+	suite.Suite
+	buf bytes.Buffer
+	sig chan os.Signal
+}
+
+func (s *CLISuite) SetupTest() {
+	s.buf.Reset()
+	s.sig = make(chan os.Signal, 1)
+}
+
+func (s *CLISuite) run(debug bool, fn func(ctx context.Context) error) int {
+	return cli.Run(cli.Options{Stderr: &s.buf, Debug: debug, Grace: time.Second, Signals: s.sig}, fn)
+}
+
+func (s *CLISuite) TestExitCode() {
+	s.Equal(cli.ExitOK, cli.ExitCode(nil))
+	s.Equal(cli.ExitFailure, cli.ExitCode(io.EOF))
+	s.Equal(cli.ExitUsage, cli.ExitCode(errx.ErrBadRequest.WithStack()))
+	s.Equal(cli.ExitUsage, cli.ExitCode(errx.ErrBadRequest.Derive("bad input").WithStack()))
+	s.Equal(cli.ExitNoInput, cli.ExitCode(errx.ErrNotFound.WithStack()))
+	s.Equal(cli.ExitUnavailable, cli.ExitCode(errx.ErrUnavailable.WithStack()))
+	s.Equal(cli.ExitNoPerm, cli.ExitCode(errx.ErrForbidden.WithStack()))
+	s.Equal(cli.ExitSoftware, cli.ExitCode(errx.ErrPanic.WithStack()))
+	s.Equal(cli.ExitTempFail, cli.ExitCode(errx.New("flaky").WithRetryable(true)))
+
+	// Регистрация кода глобальна и переживает -count, поэтому проверка до нее - на другой ошибке
+	cli.RegisterExit(errConfig, cli.ExitConfig)
+	s.Equal(cli.ExitConfig, cli.ExitCode(errx.New("outer").WithReason(errConfig)))
+	s.Equal(cli.ExitUsage, cli.ExitCode(errx.ErrBadRequest.WithStack()))
+}
+
+func (s *CLISuite) TestOK() {
+	s.Equal(cli.ExitOK, s.run(false, func(context.Context) error { return nil }))
+	s.Empty(s.buf.String())
+}
+
+func (s *CLISuite) TestError() {
+	err := errx.ErrNotFound.WithDetail("file %s", "a.txt").WithReason(io.EOF)
+
+	s.Equal(cli.ExitNoInput, s.run(false, func(context.Context) error { return err }))
+	s.Equal("> 404 Not Found (file a.txt)\n|-> EOF\n", s.buf.String())
+
+	s.buf.Reset()
+	s.Equal(cli.ExitNoInput, s.run(true, func(context.Context) error { return err }))
+	s.Contains(s.buf.String(), "cli_test.(*CLISuite).TestError()")
+}
+
+func (s *CLISuite) TestPanic() {
+	s.Equal(cli.ExitSoftware, s.run(false, func(context.Context) error { panic("boom") }))
+	s.Equal("> panic (boom)\n", s.buf.String())
+}
+
+func (s *CLISuite) TestSignal() {
+	s.sig <- os.Interrupt
+
+	code := s.run(false, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	s.Equal(cli.ExitCanceled, code)
+	s.Equal("> canceled\n", s.buf.String())
+
+	s.buf.Reset()
+	s.sig <- os.Interrupt
+
+	code = s.run(false, func(ctx context.Context) error {
+		<-ctx.Done()
+		return errx.ErrUnavailable.WithDetail("rollback failed")
+	})
+
+	s.Equal(cli.ExitCanceled, code)
+	s.True(strings.HasPrefix(s.buf.String(), "> canceled\n|-> 503 Service Unavailable (rollback failed)"))
+}
+
+func (s *CLISuite) TestForce() {
+	release := make(chan struct{})
+	defer close(release)
+
+	s.sig <- os.Interrupt
+	start := time.Now()
+
+	code := s.run(false, func(ctx context.Context) error {
+		<-ctx.Done()
+		s.sig <- os.Interrupt
+		<-release
+		return nil
+	})
+
+	s.Equal(cli.ExitCanceled, code)
+	s.Equal("> canceled\n", s.buf.String())
+	s.Less(time.Since(start), time.Second)
+}
+
+func (s *CLISuite) TestDebugMode() {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	s.T().Setenv(cli.DebugEnv, "")
+
+	for _, c := range []struct {
+		args []string
+		env  string
+		want bool
+	}{
+		{[]string{"app"}, "", false},
+		{[]string{"app", "-v", cli.DebugFlag}, "", true},
+		{[]string{"app", "--", cli.DebugFlag}, "", false},
+		{[]string{"app"}, "1", true},
+		{[]string{"app"}, "false", false},
+		{[]string{"app"}, "yes", false},
+	} {
+		os.Args = c.args
+		s.Require().NoError(os.Setenv(cli.DebugEnv, c.env))
+		s.Equal(c.want, cli.DebugMode(), "%v %q", c.args, c.env)
+		s.Equal(c.args, os.Args)
+	}
+}
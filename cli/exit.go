@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"sync"
+
+	"github.com/shestakovda/errx"
+)
+
+// Коды завершения в духе sysexits.h
+const (
+	ExitOK          = 0
+	ExitFailure     = 1   // Ошибка без известного кода
+	ExitUsage       = 64  // Неверные аргументы
+	ExitDataErr     = 65  // Неверные входные данные
+	ExitNoInput     = 66  // Входные данные не найдены
+	ExitUnavailable = 69  // Сервис недоступен
+	ExitSoftware    = 70  // Внутренняя ошибка программы
+	ExitTempFail    = 75  // Временная ошибка, можно повторить
+	ExitNoPerm      = 77  // Нет прав
+	ExitConfig      = 78  // Ошибка конфигурации
+	ExitCanceled    = 130 // Прервано по SIGINT
+)
+
+// ErrCanceled - выполнение прервано сигналом
+var ErrCanceled = errx.Define("canceled")
+
+var exits = struct {
+	sync.RWMutex
+	user     []exitCode
+	defaults []exitCode
+}{
+	// Более частные ошибки раньше общих: ErrPanic наследует ErrInternal
+	defaults: []exitCode{
+		{errx.ErrPanic, ExitSoftware},
+		{ErrCanceled, ExitCanceled},
+		{errx.ErrBadRequest, ExitUsage},
+		{errx.ErrNotAllowed, ExitUsage},
+		{errx.ErrNotAcceptable, ExitDataErr},
+		{errx.ErrUnprocessable, ExitDataErr},
+		{errx.ErrNotFound, ExitNoInput},
+		{errx.ErrUnauthorized, ExitNoPerm},
+		{errx.ErrForbidden, ExitNoPerm},
+		{errx.ErrUnavailable, ExitUnavailable},
+		{errx.ErrNotImplemented, ExitSoftware},
+		{errx.ErrInternal, ExitSoftware},
+	},
+}
+
+type exitCode struct {
+	err  errx.Error
+	code int
+}
+
+// RegisterExit - код завершения для ошибки и всех ее наследников, последние регистрации важнее
+func RegisterExit(err errx.Error, code int) {
+	exits.Lock()
+	defer exits.Unlock()
+	exits.user = append([]exitCode{{err, code}}, exits.user...)
+}
+
+// ExitCode - код завершения для ошибки: из RegisterExit, затем стандартный, иначе ExitFailure
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	exits.RLock()
+	defer exits.RUnlock()
+
+	for _, list := range [][]exitCode{exits.user, exits.defaults} {
+		for i := range list {
+			if errx.Is(err, list[i].err) {
+				return list[i].code
+			}
+		}
+	}
+
+	if errx.IsRetryable(err) {
+		return ExitTempFail
+	}
+	return ExitFailure
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/shestakovda/errx"
+)
+
+// DebugFlag - аргумент командной строки для подробного вывода ошибок, ищется до "--".
+// Из os.Args он не убирается, разбор аргументов приложения должен его допускать.
+const DebugFlag = "--debug"
+
+// DebugEnv - переменная окружения для подробного вывода ошибок, включает его только истинное значение strconv.ParseBool
+const DebugEnv = "ERRX_DEBUG"
+
+// Options - настройки запуска
+type Options struct {
+	Stderr  io.Writer        // Куда писать ошибку, по умолчанию os.Stderr
+	Debug   bool             // Выводить %+v со стеком вместо %v
+	Grace   time.Duration    // Сколько ждать завершения после сигнала, повторный сигнал завершает сразу
+	Signals <-chan os.Signal // Источник сигналов, по умолчанию SIGINT и SIGTERM процесса
+}
+
+// Main - точка входа CLI: выполняет run, печатает ошибку и завершает процесс с ее кодом.
+// По сигналу завершается сразу с ErrCanceled, для корректной остановки используйте MainContext.
+func Main(run func() error) {
+	os.Exit(Run(Options{Debug: DebugMode()}, func(context.Context) error { return run() }))
+}
+
+// MainContext - как Main, но по сигналу отменяет контекст и ждет завершения run до 5 секунд
+func MainContext(run func(ctx context.Context) error) {
+	os.Exit(Run(Options{Debug: DebugMode(), Grace: 5 * time.Second}, run))
+}
+
+// Run - выполнение run с печатью ошибки в формате %v (%+v в режиме Debug), возвращает код завершения.
+// Паника внутри run превращается в errx.ErrPanic, сигнал отменяет контекст и дает ErrCanceled.
+func Run(opts Options, run func(ctx context.Context) error) int {
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+
+	if opts.Signals == nil {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sig)
+		opts.Signals = sig
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() { done <- err }()
+		defer errx.Recover(&err)
+		err = run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-opts.Signals:
+		cancel()
+		err = ErrCanceled.WithStack()
+
+		select {
+		case exc := <-done:
+			if exc != nil && !errx.Is(exc, context.Canceled) {
+				err = ErrCanceled.WithReason(exc)
+			}
+		case <-opts.Signals:
+		case <-time.After(opts.Grace):
+		}
+	}
+
+	if err == nil {
+		return ExitOK
+	}
+
+	if opts.Debug {
+		fmt.Fprintf(opts.Stderr, "%+v\n", err)
+	} else {
+		fmt.Fprintf(opts.Stderr, "%v\n", err)
+	}
+	return ExitCode(err)
+}
+
+// DebugMode - включен ли подробный вывод флагом DebugFlag или переменной DebugEnv
+func DebugMode() bool {
+	for i, arg := range os.Args {
+		if i == 0 {
+			continue
+		}
+
+		if arg == "--" {
+			break
+		}
+
+		if arg == DebugFlag {
+			return true
+		}
+	}
+
+	on, err := strconv.ParseBool(os.Getenv(DebugEnv))
+	return err == nil && on
+}
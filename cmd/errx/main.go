@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/cli"
+)
+
+const usage = `usage: errx <command> [flags] [files]
+
+commands:
+  symbolize -bin <file>   print packed errors with raw stacks symbolized by the binary
+  scan [-json] [-top N]   group errors found in logs (plain or gzip) by fingerprint`
+
+func main() {
+	cli.MainContext(func(ctx context.Context) error {
+		return run(ctx, os.Args[1:], os.Stdin, os.Stdout)
+	})
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errx.ErrBadRequest.WithDetail(usage)
+	}
+
+	switch args[0] {
+	case "symbolize":
+		return symbolizeCmd(args[1:], stdin, stdout)
+	case "scan":
+		return scanCmd(args[1:], stdin, stdout)
+	}
+
+	return errx.ErrBadRequest.WithDetail("unknown command %q\n%s", args[0], usage)
+}
+
+// inputs - файлы из аргументов или stdin, если их нет или вместо файла указан "-"
+func inputs(files []string, stdin io.Reader, fn func(name string, r io.Reader) error) error {
+	if len(files) == 0 {
+		return fn("stdin", stdin)
+	}
+
+	for _, name := range files {
+		if name == "-" {
+			if err := fn("stdin", stdin); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := inputFile(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func inputFile(name string, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return errx.ErrNotFound.WithReason(err)
+	}
+	defer f.Close()
+
+	return fn(name, f)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/errx"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCommand(t *testing.T) {
+	suite.Run(t, new(CommandSuite))
+}
+
+type CommandSuite struct {
+	suite.Suite
+}
+
+func (s *CommandSuite) run(stdin string, args ...string) (string, error) {
+	var out bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(stdin), &out)
+	return out.String(), err
+}
+
+func (s *CommandSuite) TestUsage() {
+	_, err := s.run("")
+	s.True(errx.Is(err, errx.ErrBadRequest))
+
+	_, err = s.run("", "unknown")
+	s.True(errx.Is(err, errx.ErrBadRequest))
+
+	_, err = s.run("", "symbolize")
+	s.True(errx.Is(err, errx.ErrBadRequest))
+}
+
+func (s *CommandSuite) TestSymbolize() {
+	errx.SetStackOptions(errx.StackOptions{Raw: true})
+	buf := errx.ErrNotFound.WithDetail("user %d", 42).Pack()
+	errx.SetStackOptions(errx.StackOptions{})
+
+	exe, err := os.Executable()
+	s.Require().NoError(err)
+
+	in := "\n" + base64.StdEncoding.EncodeToString(buf) + "\n" + base64.RawURLEncoding.EncodeToString(buf) + "\n"
+	out, err := s.run(in, "symbolize", "-plain", "-bin", exe)
+	s.Require().NoError(err)
+	s.Equal(2, strings.Count(out, "> 404 Not Found (user 42)\n"))
+	s.Equal(2, strings.Count(out, "(*CommandSuite).TestSymbolize()"))
+
+	_, err = s.run("not base64!", "symbolize", "-bin", exe)
+	s.True(errx.Is(err, errx.ErrNotAcceptable))
+
+	_, err = s.run("", "symbolize", "-bin", "missing")
+	s.True(errx.Is(err, errx.ErrNotFound))
+}
+
+func (s *CommandSuite) TestScan() {
+	notFound := fmt.Sprintf("%+v", errx.ErrNotFound.WithDetail("user %d", 42).WithReason(io.EOF))
+	invalid := errx.ErrUnprocessable.WithDetail("version %d", 7)
+
+	view, err := json.Marshal(invalid.Export())
+	s.Require().NoError(err)
+
+	slog, err := json.Marshal(map[string]string{
+		"time":        "2024-05-01T12:00:00Z",
+		"level":       "ERROR",
+		"fingerprint": "00aa00aa00aa00aa",
+		"error":       notFound,
+	})
+	s.Require().NoError(err)
+
+	log := strings.Join([]string{
+		"2024-05-01T10:00:00.000Z errx [00aa00aa00aa00aa]: " + notFound,
+		"2024-05-01T11:00:00.000Z starting worker > 3 jobs",
+		"2024-05-01T11:00:00.000Z errx [00aa00aa00aa00aa]: " + notFound,
+		string(slog),
+		"time=2024-05-01T09:00:00.000Z level=ERROR fingerprint=00aa00aa00aa00aa error=" + strconv.Quote(notFound),
+		string(view),
+		"request failed packed=" + base64.RawURLEncoding.EncodeToString(invalid.Pack()),
+		"> boom",
+		"|-> EOF",
+	}, "\n")
+
+	// Тот же журнал в gzip в виде файла
+	name := filepath.Join(s.T().TempDir(), "app.log.gz")
+	file, err := os.Create(name)
+	s.Require().NoError(err)
+	zw := gzip.NewWriter(file)
+	_, err = zw.Write([]byte("> boom\n|-> EOF\n"))
+	s.Require().NoError(err)
+	s.Require().NoError(zw.Close())
+	s.Require().NoError(file.Close())
+
+	out, err := s.run(log, "scan", "-json", "-", name)
+	s.Require().NoError(err)
+
+	var groups []Group
+	s.Require().NoError(json.Unmarshal([]byte(out), &groups))
+	s.Require().Len(groups, 3)
+
+	s.Equal("00aa00aa00aa00aa", groups[0].Fingerprint)
+	s.Equal(4, groups[0].Count)
+	s.Equal("2024-05-01T09:00:00Z", groups[0].FirstSeen.UTC().Format(time.RFC3339))
+	s.Equal("2024-05-01T12:00:00Z", groups[0].LastSeen.UTC().Format(time.RFC3339))
+	s.Equal("stdin:1", groups[0].First)
+	s.Equal(fmt.Sprintf("stdin:%d", 2*strings.Count(notFound, "\n")+5), groups[0].Last)
+	s.Equal("user 42", groups[0].Error.Detail)
+	s.Equal("EOF", groups[0].Error.Next.Text)
+
+	s.Equal(invalid.Export().Fingerprint, groups[1].Fingerprint)
+	s.Equal(2, groups[1].Count)
+	s.Equal("version 7", groups[1].Error.Detail)
+
+	s.Equal(2, groups[2].Count)
+	s.Equal(name+":1", groups[2].Last)
+	s.Equal("boom", groups[2].Error.Text)
+	s.True(groups[2].FirstSeen.IsZero())
+
+	out, err = s.run(log, "scan", "-top", "1")
+	s.Require().NoError(err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	s.Require().Len(lines, 2)
+	s.Regexp(`^COUNT +FINGERPRINT +FIRST SEEN +LAST SEEN +ERROR$`, lines[0])
+	s.Regexp(`^4 +00aa00aa00aa00aa +2024-05-01T09:00:00Z +2024-05-01T12:00:00Z +404 Not Found: EOF$`, lines[1])
+
+	// Испорченные записи пропускаются, scan продолжает работу
+	broken := strings.Join([]string{
+		"2024-01-01T00:00:00Z app > boom",
+		"|       ... 2 more",
+		"request failed packed=" + base64.RawURLEncoding.EncodeToString([]byte{0xff, 0xff, 0xff, 0x7f, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}),
+	}, "\n")
+	out, err = s.run(broken, "scan", "-json", "-")
+	s.Require().NoError(err)
+	groups = nil
+	s.Require().NoError(json.Unmarshal([]byte(out), &groups))
+	s.Empty(groups)
+
+	_, err = s.run("", "scan", "missing.log")
+	s.True(errx.Is(err, errx.ErrNotFound))
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shestakovda/errx"
+)
+
+// Group - одинаковые ошибки из логов
+type Group struct {
+	Fingerprint string
+	Count       int
+	FirstSeen   time.Time // Время первого появления, если его удалось прочитать из строки лога
+	LastSeen    time.Time
+	First       string // Файл и строка первого появления
+	Last        string
+	Error       *errx.View // Цепочка первого появления
+}
+
+var (
+	packedRx = regexp.MustCompile(`[A-Za-z0-9+/_-]{40,}={0,2}`)
+	sinkRx   = regexp.MustCompile(`\[([0-9a-f]+)\]: $`)
+	fpRx     = regexp.MustCompile(`\bfingerprint=([0-9a-f]+)`)
+	timeRx   = regexp.MustCompile(`^(?:time=)?(\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})?)`)
+)
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006/01/02 15:04:05", "2006-01-02 15:04:05"}
+
+// scanCmd - поиск ошибок errx в логах и группировка по отпечатку
+func scanCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	asJSON := fs.Bool("json", false, "print groups as JSON")
+	top := fs.Int("top", 0, "print only N most frequent groups")
+
+	if err := fs.Parse(args); err != nil {
+		return errx.ErrBadRequest.WithReason(err)
+	}
+
+	sc := &scanner{groups: make(map[string]*Group)}
+
+	if err := inputs(fs.Args(), stdin, sc.read); err != nil {
+		return err
+	}
+
+	list := sc.result()
+	if *top > 0 && len(list) > *top {
+		list = list[:*top]
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	return writeTable(stdout, list)
+}
+
+type scanner struct {
+	groups map[string]*Group
+	order  []*Group
+
+	// Текущий блок вывода %+v
+	block []string
+	bare  bool // Блок начат без префикса лога
+	fp    string
+	when  time.Time
+	pos   string
+}
+
+func (sc *scanner) read(name string, r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	// Сжатые файлы узнаются по сигнатуре, а не по расширению
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return errx.ErrNotAcceptable.WithReason(err).WithDetail("%s", name)
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	scan := bufio.NewScanner(r)
+	scan.Buffer(nil, 16<<20)
+
+	for n := 1; scan.Scan(); n++ {
+		sc.line(scan.Text(), fmt.Sprintf("%s:%d", name, n))
+	}
+
+	sc.flush()
+
+	if err := scan.Err(); err != nil {
+		return errx.ErrNotAcceptable.WithReason(err).WithDetail("%s", name)
+	}
+	return nil
+}
+
+func (sc *scanner) line(line, pos string) {
+	if sc.block != nil && strings.HasPrefix(line, "|") {
+		sc.block = append(sc.block, line)
+		return
+	}
+	sc.flush()
+
+	if strings.HasPrefix(strings.TrimSpace(line), "{") {
+		var obj interface{}
+		if json.Unmarshal([]byte(line), &obj) == nil {
+			sc.json(obj, "", lineTime(line), pos)
+			return
+		}
+	}
+
+	if v := quotedText(line); v != nil {
+		var fp string
+		if m := fpRx.FindStringSubmatch(line); m != nil {
+			fp = m[1]
+		}
+		sc.add(v, fp, lineTime(line), pos)
+		return
+	}
+
+	if i := textStart(line); i >= 0 {
+		sc.block = []string{line[i:]}
+		sc.bare, sc.pos, sc.when, sc.fp = i == 0, pos, lineTime(line), ""
+
+		if m := sinkRx.FindStringSubmatch(line[:i]); m != nil {
+			sc.fp = m[1]
+		}
+		return
+	}
+
+	for _, token := range packedRx.FindAllString(line, -1) {
+		if v := unpackToken(token); v != nil {
+			sc.add(v, "", lineTime(line), pos)
+		}
+	}
+}
+
+// flush - разбор накопленного блока %+v
+func (sc *scanner) flush() {
+	block := sc.block
+	sc.block = nil
+
+	if block == nil {
+		return
+	}
+
+	// Одиночная строка с префиксом лога без отпечатка слишком похожа на обычный текст
+	if len(block) == 1 && !sc.bare && sc.fp == "" {
+		return
+	}
+
+	if v := parseText(strings.Join(block, "\n")); v != nil {
+		sc.add(v, sc.fp, sc.when, sc.pos)
+	}
+}
+
+// json - представления View и текст %+v внутри JSON записи лога
+func (sc *scanner) json(obj interface{}, fp string, when time.Time, pos string) {
+	switch val := obj.(type) {
+	case map[string]interface{}:
+		if s, ok := val["fingerprint"].(string); ok {
+			fp = s
+		}
+
+		if s, ok := val["time"].(string); ok && when.IsZero() {
+			when = parseTime(s)
+		}
+
+		if _, ok := val["Text"].(string); ok {
+			var v errx.View
+			if data, err := json.Marshal(val); err == nil && json.Unmarshal(data, &v) == nil {
+				sc.add(&v, fp, when, pos)
+			}
+			return
+		}
+
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			sc.json(val[key], fp, when, pos)
+		}
+
+	case []interface{}:
+		for i := range val {
+			sc.json(val[i], fp, when, pos)
+		}
+
+	case string:
+		if strings.HasPrefix(val, "> ") {
+			if v := parseText(val); v != nil {
+				sc.add(v, fp, when, pos)
+			}
+		}
+	}
+}
+
+func (sc *scanner) add(v *errx.View, fp string, when time.Time, pos string) {
+	if fp == "" {
+		fp = errx.ViewFingerprint(v)
+	}
+
+	g, ok := sc.groups[fp]
+	if !ok {
+		g = &Group{Fingerprint: fp, First: pos, FirstSeen: when, Error: v}
+		sc.groups[fp] = g
+		sc.order = append(sc.order, g)
+	}
+
+	g.Count++
+	g.Last = pos
+
+	if !when.IsZero() {
+		if g.FirstSeen.IsZero() || when.Before(g.FirstSeen) {
+			g.FirstSeen = when
+		}
+		if when.After(g.LastSeen) {
+			g.LastSeen = when
+		}
+	}
+}
+
+// result - группы от частых к редким, при равенстве - в порядке появления
+func (sc *scanner) result() []*Group {
+	list := append([]*Group(nil), sc.order...)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Count > list[j].Count })
+	return list
+}
+
+func writeTable(w io.Writer, list []*Group) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COUNT\tFINGERPRINT\tFIRST SEEN\tLAST SEEN\tERROR")
+
+	for _, g := range list {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", g.Count, g.Fingerprint,
+			seen(g.FirstSeen, g.First), seen(g.LastSeen, g.Last), chain(g.Error))
+	}
+	return tw.Flush()
+}
+
+func seen(t time.Time, pos string) string {
+	if t.IsZero() {
+		return pos
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// chain - тексты слоев через двоеточие, как принято для ошибок Go
+func chain(v *errx.View) string {
+	var list []string
+	for ; v != nil; v = v.Next {
+		list = append(list, v.Text)
+	}
+
+	res := strings.Join(list, ": ")
+	if utf8.RuneCountInString(res) > 100 {
+		res = string([]rune(res)[:99]) + "…"
+	}
+	return res
+}
+
+// textStart - начало вывода Format в строке: в ее начале или после префикса лога, заканчивающегося пробелом
+func textStart(line string) int {
+	if strings.HasPrefix(line, "> ") {
+		return 0
+	}
+
+	if i := strings.Index(line, " > "); i >= 0 {
+		return i + 1
+	}
+	return -1
+}
+
+// quotedText - вывод %+v в кавычках, как его пишут текстовый slog и logfmt
+func quotedText(line string) *errx.View {
+	i := strings.Index(line, `="> `)
+	if i < 0 {
+		return nil
+	}
+
+	quoted, err := strconv.QuotedPrefix(line[i+1:])
+	if err != nil {
+		return nil
+	}
+
+	text, err := strconv.Unquote(quoted)
+	if err != nil {
+		return nil
+	}
+
+	return parseText(text)
+}
+
+// parseText - разобранный вывод %+v, nil если разобрать не удалось.
+// Строки лога - чужие данные, ошибка в разборе одной записи не должна останавливать весь scan.
+func parseText(text string) (v *errx.View) {
+	defer func() {
+		if recover() != nil {
+			v = nil
+		}
+	}()
+
+	v, err := errx.ParseText(text)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// unpackToken - ошибка errx.Pack в base64, nil если это не она
+func unpackToken(token string) (v *errx.View) {
+	buf, err := decode(token)
+	if err != nil || len(buf) < 16 {
+		return nil
+	}
+
+	// Случайные данные в base64 отсекаются проверками ParsePacked, а затем по тексту
+	res, err := errx.ParsePacked(buf)
+	if err != nil {
+		return nil
+	}
+
+	v = res.Export()
+	if v.Text == "" || !utf8.ValidString(v.Text) || strings.IndexFunc(v.Text, unicode.IsControl) >= 0 {
+		return nil
+	}
+	return v
+}
+
+func lineTime(line string) time.Time {
+	if m := timeRx.FindStringSubmatch(line); m != nil {
+		return parseTime(m[1])
+	}
+	return time.Time{}
+}
+
+func parseTime(s string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
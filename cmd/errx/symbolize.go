@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"flag"
+	"io"
+	"strings"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/symbolize"
+)
+
+// symbolizeCmd - вывод ошибок errx.Pack в base64, по одной на строку, со стеком по файлу сборки
+func symbolizeCmd(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("symbolize", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	bin := fs.String("bin", "", "binary that produced the errors")
+	plain := fs.Bool("plain", false, "disable colors")
+
+	if err := fs.Parse(args); err != nil {
+		return errx.ErrBadRequest.WithReason(err)
+	}
+
+	if *bin == "" {
+		return errx.ErrBadRequest.WithDetail("symbolize: -bin is required")
+	}
+
+	table, err := symbolize.Open(*bin)
+	if err != nil {
+		return errx.ErrNotFound.WithReason(err).WithDebug(errx.Debug{"bin": *bin})
+	}
+
+	opts := errx.RenderOptions{}
+	if *plain {
+		opts.Color = errx.ColorNever
+	}
+
+	return inputs(fs.Args(), stdin, func(name string, r io.Reader) error {
+		scan := bufio.NewScanner(r)
+		scan.Buffer(nil, 16<<20)
+
+		for line := 1; scan.Scan(); line++ {
+			text := strings.TrimSpace(scan.Text())
+			if text == "" {
+				continue
+			}
+
+			buf, err := decode(text)
+			if err != nil {
+				return errx.ErrNotAcceptable.WithReason(err).WithDetail("%s:%d", name, line)
+			}
+
+			res, err := table.Unpack(buf)
+			if err != nil {
+				return errx.ErrNotAcceptable.WithReason(err).WithDetail("%s:%d", name, line)
+			}
+
+			if err = errx.Render(stdout, res, opts); err != nil {
+				return err
+			}
+		}
+
+		return scan.Err()
+	})
+}
+
+// decode - base64 в обычном или URL варианте, с дополнением или без
+func decode(text string) ([]byte, error) {
+	text = strings.TrimRight(text, "=")
+
+	if strings.ContainsAny(text, "-_") {
+		return base64.RawURLEncoding.DecodeString(text)
+	}
+	return base64.RawStdEncoding.DecodeString(text)
+}
@@ -1,18 +1,54 @@
 package errx
 
+import (
+	"errors"
+	"strconv"
+)
+
 // Standard HTTP errors
 var (
 	// 4хх
-	ErrBadRequest    = New("400 Bad Request")
-	ErrUnauthorized  = New("401 Unauthorized")
-	ErrForbidden     = New("403 Forbidden")
-	ErrNotFound      = New("404 Not Found")
-	ErrNotAllowed    = New("405 Method Not Allowed")
-	ErrNotAcceptable = New("406 Not Acceptable")
-	ErrUnprocessable = New("422 Unprocessable Entity")
+	ErrBadRequest    = Define("400 Bad Request")
+	ErrUnauthorized  = Define("401 Unauthorized")
+	ErrForbidden     = Define("403 Forbidden")
+	ErrNotFound      = Define("404 Not Found")
+	ErrNotAllowed    = Define("405 Method Not Allowed")
+	ErrNotAcceptable = Define("406 Not Acceptable")
+	ErrUnprocessable = Define("422 Unprocessable Entity")
 
 	// 5хх
-	ErrInternal       = New("500 Internal Server Error")
-	ErrNotImplemented = New("501 Not Implemented")
-	ErrUnavailable    = New("503 Service Unavailable")
+	ErrInternal       = Define("500 Internal Server Error")
+	ErrNotImplemented = Define("501 Not Implemented")
+	ErrUnavailable    = Define("503 Service Unavailable")
 )
+
+// Status - HTTP-статус ошибки по тексту ближайшего прототипа вида "404 Not Found", 0 если его нет.
+// Слои цепочки проверяются сверху вниз, поэтому внешняя ошибка важнее причины.
+func Status(err error) int {
+	for ; err != nil; err = errors.Unwrap(err) {
+		e, ok := err.(*v1Error)
+
+		if !ok {
+			continue
+		}
+
+		for p := e; p != nil; p = p.proto {
+			if code := textStatus(p.text); code != 0 {
+				return code
+			}
+		}
+	}
+	return 0
+}
+
+func textStatus(text string) int {
+	if len(text) < 4 || text[3] != ' ' {
+		return 0
+	}
+
+	code, err := strconv.Atoi(text[:3])
+	if err != nil || code < 100 || code > 599 {
+		return 0
+	}
+	return code
+}
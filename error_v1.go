@@ -3,18 +3,15 @@ package errx
 import (
 	"errors"
 	"fmt"
-	"path"
-	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/kr/pretty"
 
 	fbs "github.com/google/flatbuffers/go"
 )
 
-const stackTpl = "%s:%d -> %s()"
-
 var fbsPool = sync.Pool{New: func() interface{} { return fbs.NewBuilder(128) }}
 
 func newErrorV1(text string) Error {
@@ -24,22 +21,43 @@ func newErrorV1(text string) Error {
 }
 
 func unpackV1(buf []byte) Error {
-	return new(v1Error).importModel(GetRootAsErrorModel(buf, 0).UnPack())
+	err := new(v1Error).importModel(GetRootAsErrorModel(buf, 0).UnPack())
+	notify(EventUnpack, err)
+	return err
 }
 
 type v1Error struct {
-	deep   int
-	text   string
-	detail string
-	stack  []string
-	debug  map[string]string
-	proto  *v1Error
-	reason error
+	fp         string
+	tpl        string
+	text       string
+	detail     string
+	msg        string            // Ключ сообщения из каталога
+	params     map[string]string // Параметры сообщения
+	id         string            // Идентификатор корреляции
+	public     byte              // Явная отметка публичного или внутреннего слоя
+	violations []Violation       // Нарушения проверки полей запроса
+	stack      []Frame
+	raw        *RawStack
+	symbols    []Frame // Кадры по адресам raw, символизируются один раз при первом обращении
+	symOnce    sync.Once
+	mode       StackMode
+	debug      map[string]string
+	proto      *v1Error
+	reason     error
+	retry      byte
+	after      time.Duration
 }
 
 func (e *v1Error) Error() string    { return e.text }
 func (e *v1Error) Unwrap() error    { return e.reason }
-func (e *v1Error) WithStack() Error { return e.withStack() }
+func (e *v1Error) WithStack() Error { return e.created(e.withStack()) }
+
+func (e *v1Error) Derive(text string) Error {
+	return &v1Error{
+		text:  text,
+		proto: e,
+	}
+}
 
 func (e *v1Error) Is(err error) bool {
 	if err == nil {
@@ -50,8 +68,11 @@ func (e *v1Error) Is(err error) bool {
 		return true
 	}
 
-	if e.text == err.Error() {
-		return true
+	// Две зарегистрированные ошибки сравниваются только по происхождению, не по тексту
+	if t, ok := err.(*v1Error); !ok || t.sentinel() != t || e.sentinel() == nil {
+		if e.text == err.Error() {
+			return true
+		}
 	}
 
 	if e.proto != nil && errors.Is(e.proto, err) {
@@ -68,13 +89,17 @@ func (e *v1Error) Is(err error) bool {
 func (e *v1Error) WithReason(reason error) Error {
 	err := e.withStack()
 	err.reason = reason
+	e.created(err)
+	notify(EventWrap, err)
 	return err
 }
 
 func (e *v1Error) WithDetail(tpl string, args ...interface{}) Error {
 	err := e.withStack()
+	err.tpl = tpl
 	err.detail = fmt.Sprintf(tpl, args...)
-	return err
+	err.msg, err.params = "", nil
+	return e.created(err)
 }
 
 func (e *v1Error) WithDebug(items Debug) Error {
@@ -83,16 +108,36 @@ func (e *v1Error) WithDebug(items Debug) Error {
 	for key := range items {
 		err.debug[key] = fmt.Sprintf("%#v", pretty.Formatter(items[key]))
 	}
-	return err
+	return e.created(err)
 }
 
-func (e *v1Error) Format(f fmt.State, r rune) {
+func (e *v1Error) WithRetryable(ok bool) Error {
+	err := e.withStack()
+	if ok {
+		err.retry = retryYes
+	} else {
+		err.retry = retryNo
+	}
+	return e.created(err)
+}
+
+func (e *v1Error) WithRetryAfter(d time.Duration) Error {
+	err := e.withStack()
+	err.after = d
+	return e.created(err)
+}
+
+func (e *v1Error) Format(f fmt.State, r rune) { e.format(f, r, nil, 0) }
+
+// format - вывод слоя и причин, outer - кадры внешнего слоя, depth - номер слоя в цепочке.
+// Состояние передается аргументами: одна и та же причина может выводиться из разных горутин.
+func (e *v1Error) format(f fmt.State, r rune, outer []Frame, depth int) {
 	// Сначала всегда на той же строке основное сообщение
 	fmt.Fprintf(f, "> %s", e.text)
 
 	// Затем в скобках детализация для пользователя
-	if e.detail != "" {
-		fmt.Fprintf(f, " (%s)", e.detail)
+	if detail := e.userDetail(); detail != "" {
+		fmt.Fprintf(f, " (%s)", detail)
 	}
 
 	// Если не нужна детальная инфа, на этом все
@@ -100,126 +145,322 @@ func (e *v1Error) Format(f fmt.State, r rune) {
 		return
 	}
 
-	// Затем, на каждой строчке со сдвигом и кареткой, отладка (если есть)
-	for key := range e.debug {
+	// Затем, на каждой строчке со сдвигом и кареткой, идентификатор корреляции, нарушения и отладка (если есть), ключи по алфавиту
+	if e.id != "" {
+		fmt.Fprintf(f, "\n|   %s: %s", CorrelationKey, e.id)
+	}
+
+	for _, v := range e.violations {
+		fmt.Fprintf(f, "\n|   %s%s: %s", violationMark, v.Path, v.Text())
+
+		if v.Code != "" {
+			fmt.Fprintf(f, " [%s]", v.Code)
+		}
+	}
+
+	for _, key := range sortedKeys(e.debug) {
 		fmt.Fprintf(f, "\n|   %s: %s", key, e.debug[key])
 	}
 
-	// Затем, если нужны подробности, выводим стек
-	if f.Flag('+') && len(e.stack) > 0 {
-		fmt.Fprintf(f, "\n|       %s", strings.Join(e.stack, "\n|       "))
+	// Затем, если нужны подробности, выводим стек.
+	// Кадры, общие с внешним слоем, уже выведены выше, поэтому вместо них только их количество.
+	if f.Flag('+') {
+		stack, more := e.frames(), 0
+
+		if outer != nil {
+			more = sharedFrames(stack, outer)
+			stack = stack[:len(stack)-more]
+		}
+
+		if lines := stackLines(stack); len(lines) > 0 {
+			fmt.Fprintf(f, "\n|       %s", strings.Join(lines, "\n|       "))
+		}
+
+		if more > 0 {
+			fmt.Fprintf(f, "\n|       ... %d more", more)
+		}
 	}
 
 	// Затем, если есть кто-то в цепочке, выводим его со след. строки
-	if e.reason != nil && e.deep < 10 {
-		e.deep++
-
-		if next, ok := e.reason.(Error); ok {
-			if f.Flag('+') {
-				fmt.Fprintf(f, "\n|-%+v", next)
-			} else {
-				fmt.Fprintf(f, "\n|-%v", next)
-			}
+	if e.reason == nil || depth >= 10 {
+		return
+	}
+
+	if next, ok := e.reason.(*v1Error); ok {
+		fmt.Fprint(f, "\n|-")
+		next.format(f, r, e.frames(), depth+1)
+	} else if next, ok := e.reason.(Error); ok {
+		if f.Flag('+') {
+			fmt.Fprintf(f, "\n|-%+v", next)
 		} else {
-			fmt.Fprintf(f, "\n|-> %s", e.reason)
+			fmt.Fprintf(f, "\n|-%v", next)
 		}
+	} else {
+		fmt.Fprintf(f, "\n|-> %s", e.reason)
 	}
-	e.deep = 0
 }
 
-func (e *v1Error) Export() *View {
+func (e *v1Error) Export() *View { return e.export(0) }
+
+// export - представление слоя и не более 10 причин под ним, depth - номер слоя в цепочке.
+// Состояние передается аргументами, как в format: одна и та же причина может выгружаться из разных горутин.
+func (e *v1Error) export(depth int) *View {
 	v := &View{
-		Text:   e.text,
-		Detail: e.detail,
-		Stack:  e.stack,
-		Debug:  e.debug,
+		Text:          e.text,
+		Detail:        e.userDetail(),
+		Message:       e.msg,
+		Params:        e.params,
+		Stack:         formatFrames(e.frames()),
+		Frames:        e.frames(),
+		Raw:           e.raw,
+		Debug:         e.debug,
+		CorrelationID: e.id,
+		Violations:    e.violations,
+		StackMode:     e.mode,
 	}
 
-	if e.reason != nil && e.deep < 10 {
-		e.deep++
-		if next, ok := e.reason.(Error); ok {
+	if e.reason != nil && depth < 10 {
+		if next, ok := e.reason.(*v1Error); ok {
+			v.Next = next.export(depth + 1)
+		} else if next, ok := e.reason.(Error); ok {
 			v.Next = next.Export()
 		} else {
-			v.Next = &View{Text: e.reason.Error()}
+			v.Next = &View{Text: e.reason.Error(), Fingerprint: Fingerprint(e.reason)}
 		}
 	}
-	e.deep = 0
+
+	// Отпечаток причины уже посчитан при ее экспорте, поэтому вся цепочка проходится один раз
+	if v.Next != nil {
+		v.Fingerprint = e.fingerprint(v.Next.Fingerprint)
+	} else {
+		v.Fingerprint = Fingerprint(e)
+	}
 	return v
 }
 
 func (e *v1Error) Pack() []byte {
+	notify(EventPack, e)
 	buf := fbsPool.Get().(*fbs.Builder)
-	buf.Finish(e.exportModel().Pack(buf))
-	res := buf.FinishedBytes()
+	buf.Finish(e.exportModel(0).Pack(buf))
+	// Буфер вернется в пул и будет переписан, поэтому результат копируем
+	res := append([]byte(nil), buf.FinishedBytes()...)
 	buf.Reset()
 	fbsPool.Put(buf)
 	return res
 }
 
 func (e *v1Error) withStack() *v1Error {
-	var frame int
-
 	err := &v1Error{
-		text:   e.text,
-		detail: e.detail,
-		debug:  e.debug,
-		reason: e.reason,
-		proto:  e,
-		stack:  make([]string, 0, 16),
-	}
-
-	for {
-		frame++
-		if pc, file, line, ok := runtime.Caller(frame); ok {
-			err.stack = append(err.stack, fmt.Sprintf(
-				stackTpl, path.Base(file), line, path.Base(runtime.FuncForPC(pc).Name()),
-			))
-		} else {
-			break
-		}
+		tpl:        e.tpl,
+		text:       e.text,
+		detail:     e.detail,
+		msg:        e.msg,
+		params:     e.params,
+		id:         e.id,
+		debug:      e.debug,
+		violations: e.violations,
+		reason:     e.reason,
+		retry:      e.retry,
+		after:      e.after,
+		proto:      e,
+	}
+	e.capture(err, 2)
+	return err
+}
+
+// frames - стек слоя, адреса символизируются только если собраны этим же процессом
+func (e *v1Error) frames() []Frame {
+	if len(e.stack) == 0 && e.raw != nil && e.raw.local() {
+		e.symOnce.Do(func() { e.symbols = e.raw.symbolize() })
+		return e.symbols
 	}
+	return e.stack
+}
 
+// created - оповещение подписчиков, если ошибка только что получена из шаблона
+func (e *v1Error) created(err *v1Error) Error {
+	if e.stack == nil && e.raw == nil {
+		notify(EventCreate, err)
+	}
 	return err
 }
 
-func (e *v1Error) exportModel() *ErrorModelT {
+// sentinel - ближайшая зарегистрированная ошибка в цепочке прототипов
+func (e *v1Error) sentinel() *v1Error {
+	for p := e; p != nil; p = p.proto {
+		if _, ok := origin(p); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// lineage - цепочка прототипов слоя для передачи через Pack.
+// Копии с одинаковым текстом схлопываются в одну запись с пакетом первой зарегистрированной.
+// Запись самого слоя возвращается отдельно, остальные - предки от ближнего к дальнему.
+func (e *v1Error) lineage() (string, []*ProtoModelT) {
+	cur := &ProtoModelT{Text: e.text}
+	all := []*ProtoModelT{cur}
+
+	for p := e; p != nil; p = p.proto {
+		if p.text != cur.Text {
+			cur = &ProtoModelT{Text: p.text}
+			all = append(all, cur)
+		}
+
+		if cur.Origin == "" {
+			if org, ok := origin(p); ok {
+				cur.Origin = org.Package
+			}
+		}
+	}
+
+	if len(all) == 1 {
+		return cur.Origin, nil
+	}
+	return all[0].Origin, all[1:]
+}
+
+// exportModel - модель слоя и не более 10 причин под ним для Pack, depth - номер слоя в цепочке
+func (e *v1Error) exportModel(depth int) *ErrorModelT {
 	m := &ErrorModelT{
-		Text:   e.text,
-		Detail: e.detail,
-		Stack:  e.stack,
-		Debug:  make([]*KeyValueT, 0, len(e.debug)),
+		Text:          e.text,
+		Detail:        e.userDetail(),
+		Message:       e.msg,
+		Stack:         formatFrames(e.stack),
+		Debug:         make([]*KeyValueT, 0, len(e.debug)),
+		Retry:         e.retry,
+		RetryAfter:    int64(e.after),
+		StackMode:     byte(e.mode),
+		Public:        publicNo,
+		CorrelationId: e.id,
+	}
+
+	if e.isPublic() {
+		m.Public = publicYes
+	}
+
+	m.Origin, m.Protos = e.lineage()
+
+	for i, f := range e.stack {
+		if src := f.source(); src != "" {
+			if m.FramePaths == nil {
+				m.FramePaths = make([]string, len(e.stack))
+			}
+			m.FramePaths[i] = src
+		}
 	}
 
-	for k, v := range e.debug {
+	if e.raw != nil {
+		m.Pcs = e.raw.PCs
+		m.BuildId = e.raw.BuildID
+		m.Module = e.raw.Module
+		m.PcBase = int64(e.raw.Base)
+	}
+
+	// Порядок ключей фиксирован, чтобы упаковка одной и той же ошибки давала одинаковые байты
+	for _, k := range sortedKeys(e.debug) {
 		m.Debug = append(m.Debug, &KeyValueT{
 			Key:   k,
-			Value: v,
+			Value: e.debug[k],
+		})
+	}
+
+	for _, k := range sortedKeys(e.params) {
+		m.Params = append(m.Params, &KeyValueT{
+			Key:   k,
+			Value: e.params[k],
 		})
 	}
 
-	if e.reason != nil && e.deep < 10 {
-		e.deep++
+	for _, v := range e.violations {
+		vm := &ViolationModelT{Path: v.Path, Code: v.Code, Message: v.Message, Detail: v.Detail}
+		for _, k := range sortedKeys(v.Params) {
+			vm.Params = append(vm.Params, &KeyValueT{Key: k, Value: v.Params[k]})
+		}
+		m.Violations = append(m.Violations, vm)
+	}
+
+	if e.reason != nil && depth < 10 {
 		if next, ok := e.reason.(*v1Error); ok {
-			m.Next = next.exportModel()
+			m.Next = next.exportModel(depth + 1)
 		} else {
-			m.Next = &ErrorModelT{Text: e.reason.Error()}
+			m.Next = &ErrorModelT{Text: e.reason.Error(), Fingerprint: Fingerprint(e.reason)}
 		}
 	}
 
-	e.deep = 0
+	if m.Next != nil {
+		m.Fingerprint = e.fingerprint(m.Next.Fingerprint)
+	} else {
+		m.Fingerprint = Fingerprint(e)
+	}
 	return m
 }
 
 func (e *v1Error) importModel(m *ErrorModelT) *v1Error {
+	e.fp = m.Fingerprint
 	e.text = m.Text
 	e.detail = m.Detail
-	e.stack = m.Stack
+	e.stack = parseFrames(m.Stack)
+
+	if len(m.FramePaths) == len(e.stack) {
+		for i := range e.stack {
+			e.stack[i] = e.stack[i].parseSource(m.FramePaths[i])
+		}
+	}
+	e.mode = StackMode(m.StackMode)
+
+	if len(m.Pcs) > 0 {
+		e.raw = &RawStack{
+			PCs:     m.Pcs,
+			BuildID: m.BuildId,
+			Module:  m.Module,
+			Base:    uint64(m.PcBase),
+		}
+	}
+	e.retry = m.Retry
+	e.after = time.Duration(m.RetryAfter)
 	e.debug = make(map[string]string, len(m.Debug))
 
+	// Прототипы восстанавливаем от дальнего к ближнему, зарегистрированные берем как есть
+	for i := len(m.Protos) - 1; i >= 0; i-- {
+		if p := lookup(m.Protos[i].Origin, m.Protos[i].Text); p != nil {
+			e.proto = p
+		} else {
+			e.proto = &v1Error{text: m.Protos[i].Text, proto: e.proto}
+		}
+	}
+
+	if p := lookup(m.Origin, m.Text); p != nil {
+		e.proto = p
+	}
+
 	for i := range m.Debug {
 		e.debug[m.Debug[i].Key] = m.Debug[i].Value
 	}
 
+	e.msg = m.Message
+	e.id = m.CorrelationId
+	e.public = m.Public
+
+	if len(m.Params) > 0 {
+		e.params = make(map[string]string, len(m.Params))
+		for i := range m.Params {
+			e.params[m.Params[i].Key] = m.Params[i].Value
+		}
+	}
+
+	for _, vm := range m.Violations {
+		v := Violation{Path: vm.Path, Code: vm.Code, Message: vm.Message, Detail: vm.Detail}
+		if len(vm.Params) > 0 {
+			v.Params = make(map[string]string, len(vm.Params))
+			for i := range vm.Params {
+				v.Params[vm.Params[i].Key] = vm.Params[i].Value
+			}
+		}
+		e.violations = append(e.violations, v)
+	}
+
 	if m.Next != nil {
 		e.reason = new(v1Error).importModel(m.Next)
 	}
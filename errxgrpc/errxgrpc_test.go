@@ -0,0 +1,224 @@
+package errxgrpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/errxgrpc"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var (
+	errUserNotFound = errx.Register(errx.ErrNotFound.Derive("user not found"))
+	errQuota        = errx.Define("quota exceeded")
+	errDailyQuota   = errx.Register(errQuota.Derive("daily quota exceeded"))
+)
+
+func init() {
+	// Наследник зарегистрирован раньше шаблона, но его код все равно важнее
+	errxgrpc.Register(errDailyQuota, codes.Unavailable)
+	errxgrpc.Register(errQuota, codes.ResourceExhausted)
+}
+
+func TestGRPC(t *testing.T) {
+	suite.Run(t, new(GRPCSuite))
+}
+
+type GRPCSuite struct {
+	suite.Suite
+	srv  *grpc.Server
+	conn *grpc.ClientConn
+	err  error
+}
+
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	s *GRPCSuite
+}
+
+func (h healthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	return nil, h.s.err
+}
+
+func (h healthServer) Watch(_ *grpc_health_v1.HealthCheckRequest, ss grpc_health_v1.Health_WatchServer) error {
+	if err := ss.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}); err != nil {
+		return err
+	}
+	return h.s.err
+}
+
+func (s *GRPCSuite) SetupSuite() {
+	lis := bufconn.Listen(1 << 20)
+
+	// Клиент теста - доверенный сервис, поэтому цепочка передается целиком
+	s.srv = grpc.NewServer(
+		grpc.UnaryInterceptor(errxgrpc.UnaryServerInterceptor(errxgrpc.WithInternal())),
+		grpc.StreamInterceptor(errxgrpc.StreamServerInterceptor(errxgrpc.WithInternal())),
+	)
+	grpc_health_v1.RegisterHealthServer(s.srv, healthServer{s: s})
+	go s.srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(errxgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(errxgrpc.StreamClientInterceptor()),
+	)
+	s.Require().NoError(err)
+	s.conn = conn
+}
+
+func (s *GRPCSuite) TearDownSuite() {
+	s.conn.Close()
+	s.srv.Stop()
+}
+
+func (s *GRPCSuite) TestCode() {
+	s.Equal(codes.OK, errxgrpc.Code(nil))
+	s.Equal(codes.NotFound, errxgrpc.Code(errUserNotFound.WithStack()))
+	s.Equal(codes.Internal, errxgrpc.Code(errx.ErrInternal.WithReason(errUserNotFound)))
+	s.Equal(codes.ResourceExhausted, errxgrpc.Code(errx.ErrInternal.WithReason(errQuota)))
+	s.Equal(codes.Unavailable, errxgrpc.Code(errDailyQuota.WithStack()))
+	s.Equal(codes.Canceled, errxgrpc.Code(context.Canceled))
+	s.Equal(codes.Unknown, errxgrpc.Code(io.EOF))
+	s.Equal(codes.Internal, errxgrpc.Code(errx.New("502 Bad Gateway")))
+	s.Equal(codes.NotFound, errxgrpc.Code(status.Error(codes.NotFound, "no user")))
+
+	st := errxgrpc.Status(errUserNotFound.WithDetail("id %d", 42))
+	s.Equal(codes.NotFound, st.Code())
+	s.Equal("user not found", st.Message())
+	s.Len(st.Proto().Details, 1)
+
+	err := errxgrpc.FromStatus(st)
+	s.True(errx.Is(err, errUserNotFound))
+	s.Equal("id 42", err.(errx.Error).Export().Detail)
+
+	err = errxgrpc.FromStatus(status.New(codes.Unavailable, "try later"))
+	s.True(errx.Is(err, errx.ErrUnavailable))
+	s.True(errx.IsRetryable(err))
+	s.Equal("try later", err.(errx.Error).Export().Detail)
+
+	err = errxgrpc.FromStatus(status.New(codes.DataLoss, "oops"))
+	s.True(errx.Is(err, errx.ErrInternal))
+	s.Equal("DataLoss: oops", err.(errx.Error).Export().Detail)
+
+	// Испорченная деталь не роняет клиента, ошибка получается по коду
+	pb := status.New(codes.NotFound, "no user").Proto()
+	pb.Details = append(pb.Details, &anypb.Any{TypeUrl: errxgrpc.PackedTypeURL, Value: []byte{0xff, 0xff, 0xff, 0x7f, 1, 2, 3, 4, 5}})
+	err = errxgrpc.FromStatus(status.FromProto(pb))
+	s.True(errx.Is(err, errx.ErrNotFound))
+	s.Equal("no user", err.(errx.Error).Export().Detail)
+
+	s.NoError(errxgrpc.FromStatus(status.New(codes.OK, "")))
+	s.Equal(io.EOF, errxgrpc.FromError(io.EOF))
+
+	// Встроенные ошибки переживают передачу одним кодом, без упакованной цепочки
+	builtin := []errx.Error{
+		errx.ErrBadRequest, errx.ErrUnauthorized, errx.ErrForbidden, errx.ErrNotFound, errx.ErrNotAllowed,
+		errx.ErrNotAcceptable, errx.ErrUnprocessable, errx.ErrInternal, errx.ErrNotImplemented, errx.ErrUnavailable,
+	}
+	for i := range builtin {
+		res := errxgrpc.FromStatus(status.New(errxgrpc.Code(builtin[i].WithStack()), ""))
+		for j := range builtin {
+			s.Equal(i == j, errx.Is(res, builtin[j]), "%v -> %v is %v", builtin[i], res, builtin[j])
+		}
+	}
+}
+
+func (s *GRPCSuite) TestPublic() {
+	err := errx.New("query failed").WithReason(errUserNotFound.WithDetail("id %d", 42).WithReason(io.EOF)).WithCorrelationID("c1")
+
+	st := errxgrpc.Status(err)
+	s.Equal(codes.NotFound, st.Code())
+	s.Equal("user not found", st.Message())
+
+	res := errxgrpc.FromStatus(st)
+	s.True(errx.Is(res, errUserNotFound))
+	s.False(errx.Is(res, io.EOF))
+	s.Equal("c1", errx.CorrelationID(res))
+	s.Equal(errx.Fingerprint(err), errx.Fingerprint(res))
+
+	view := res.(errx.Error).Export()
+	s.Equal("id 42", view.Detail)
+	s.Empty(view.Stack)
+	s.Nil(view.Next)
+
+	// Внутренняя цепочка целиком уходит только через InternalStatus
+	st = errxgrpc.Status(errQuota.WithDetail("limit %d", 10))
+	s.Equal(codes.ResourceExhausted, st.Code())
+	s.Equal(errx.ErrInternal.Error(), st.Message())
+	s.True(errx.Is(errxgrpc.FromStatus(st), errx.ErrInternal))
+
+	s.True(errx.Is(errxgrpc.FromStatus(errxgrpc.InternalStatus(err)), io.EOF))
+}
+
+func (s *GRPCSuite) TestUnary() {
+	cli := grpc_health_v1.NewHealthClient(s.conn)
+
+	s.err = errx.ErrInternal.WithReason(errUserNotFound.WithDetail("id %d", 42).WithReason(io.EOF))
+	_, err := cli.Check(context.Background(), new(grpc_health_v1.HealthCheckRequest))
+
+	s.True(errx.Is(err, errx.ErrInternal))
+	s.True(errx.Is(err, errUserNotFound))
+	s.True(errx.Is(err, errx.ErrNotFound))
+	s.True(errx.Is(err, io.EOF))
+	s.Equal(codes.Internal, errxgrpc.Code(err))
+	s.Equal(errx.Fingerprint(s.err), errx.Fingerprint(err))
+	s.Len(errx.CorrelationID(err), 16)
+
+	// Идентификатор корреляции из метаданных запроса, негодный заменяется новым
+	ctx := metadata.AppendToOutgoingContext(context.Background(), errxgrpc.CorrelationMetadata, "req-1")
+	_, err = cli.Check(ctx, new(grpc_health_v1.HealthCheckRequest))
+	s.Equal("req-1", errx.CorrelationID(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), errxgrpc.CorrelationMetadata, "<bad id>")
+	_, err = cli.Check(ctx, new(grpc_health_v1.HealthCheckRequest))
+	s.Len(errx.CorrelationID(err), 16)
+
+	s.err = status.Error(codes.AlreadyExists, "dup")
+	_, err = cli.Check(context.Background(), new(grpc_health_v1.HealthCheckRequest))
+	s.True(errx.Is(err, errx.ErrInternal))
+	s.Equal("AlreadyExists: dup", err.(errx.Error).Export().Detail)
+
+	s.err = nil
+	_, err = cli.Check(context.Background(), new(grpc_health_v1.HealthCheckRequest))
+	s.NoError(err)
+}
+
+func (s *GRPCSuite) TestStream() {
+	cli := grpc_health_v1.NewHealthClient(s.conn)
+
+	s.err = errQuota.WithDetail("limit %d", 10)
+	stream, err := cli.Watch(context.Background(), new(grpc_health_v1.HealthCheckRequest))
+	s.Require().NoError(err)
+
+	_, err = stream.Recv()
+	s.NoError(err)
+
+	_, err = stream.Recv()
+	s.True(errx.Is(err, errQuota))
+	s.Equal(codes.ResourceExhausted, errxgrpc.Code(err))
+	s.Equal("limit 10", err.(errx.Error).Export().Detail)
+	s.Len(errx.CorrelationID(err), 16)
+
+	s.err = nil
+	stream, err = cli.Watch(context.Background(), new(grpc_health_v1.HealthCheckRequest))
+	s.Require().NoError(err)
+
+	_, err = stream.Recv()
+	s.NoError(err)
+
+	_, err = stream.Recv()
+	s.Equal(io.EOF, err)
+}
@@ -0,0 +1,117 @@
+package errxgrpc
+
+import (
+	"context"
+	"io"
+
+	"github.com/shestakovda/errx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// CorrelationMetadata - ключ метаданных запроса с идентификатором корреляции
+const CorrelationMetadata = "x-correlation-id"
+
+// Option - настройка серверных перехватчиков
+type Option func(*config)
+
+type config struct {
+	status func(err error) *status.Status
+}
+
+// WithInternal - отдавать всю цепочку со стеком и отладкой (InternalStatus) вместо публичной проекции.
+// Только для серверов, клиенты которых - доверенные сервисы.
+func WithInternal() Option {
+	return func(c *config) {
+		c.status = InternalStatus
+	}
+}
+
+// UnaryServerInterceptor - перевод ошибок обработчиков в статусы, по умолчанию публичные (Status).
+// Ошибке без идентификатора корреляции назначается идентификатор из метаданных CorrelationMetadata,
+// если он проходит errx.ValidCorrelationID, иначе новый.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	toStatus := newConfig(opts).toStatus
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, toStatus(withCorrelation(ctx, err))
+	}
+}
+
+// StreamServerInterceptor - перевод ошибок потоковых обработчиков в статусы, по умолчанию публичные (Status).
+// Идентификатор корреляции назначается так же, как в UnaryServerInterceptor.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	toStatus := newConfig(opts).toStatus
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(withCorrelation(ss.Context(), handler(srv, ss)))
+	}
+}
+
+// withCorrelation - ошибка с идентификатором корреляции из метаданных запроса или новым.
+// Статусы gRPC и ошибки без errx не меняются, в них идентификатор передать некуда.
+func withCorrelation(ctx context.Context, err error) error {
+	e, ok := err.(errx.Error)
+	if !ok || errx.CorrelationID(err) != "" {
+		return err
+	}
+
+	var id string
+	if list := metadata.ValueFromIncomingContext(ctx, CorrelationMetadata); len(list) > 0 && errx.ValidCorrelationID(list[0]) {
+		id = list[0]
+	}
+	return e.WithCorrelationID(id)
+}
+
+// UnaryClientInterceptor - восстановление цепочки errx из статуса ответа
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return FromError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor - восстановление цепочки errx из статусов потока
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, FromError(err)
+		}
+		return &clientStream{ClientStream: cs}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	return fromStreamError(s.ClientStream.SendMsg(m))
+}
+func (s *clientStream) RecvMsg(m interface{}) error {
+	return fromStreamError(s.ClientStream.RecvMsg(m))
+}
+func (s *clientStream) CloseSend() error { return fromStreamError(s.ClientStream.CloseSend()) }
+
+// fromStreamError - io.EOF означает нормальное завершение потока и не меняется
+func fromStreamError(err error) error {
+	if err == io.EOF {
+		return err
+	}
+	return FromError(err)
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{status: Status}
+	for i := range opts {
+		opts[i](cfg)
+	}
+	return cfg
+}
+
+func (c *config) toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	return c.status(err).Err()
+}
@@ -0,0 +1,190 @@
+package errxgrpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/shestakovda/errx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// PackedTypeURL - тип детали статуса, в которой лежит errx.Error.Pack()
+const PackedTypeURL = "type.googleapis.com/errx.Packed"
+
+// Соответствие HTTP-статусов кодам gRPC. Для встроенных ошибок errx оно взаимно однозначно с codeErrors,
+// поэтому шаблон переживает передачу без упакованной цепочки. У 405 и 406 точного кода нет,
+// им отданы свободные коды ошибок клиента Aborted и OutOfRange.
+var httpCodes = map[int]codes.Code{
+	400: codes.InvalidArgument,
+	401: codes.Unauthenticated,
+	403: codes.PermissionDenied,
+	404: codes.NotFound,
+	405: codes.Aborted,
+	406: codes.OutOfRange,
+	409: codes.AlreadyExists,
+	412: codes.FailedPrecondition,
+	422: codes.FailedPrecondition,
+	429: codes.ResourceExhausted,
+	499: codes.Canceled,
+	500: codes.Internal,
+	501: codes.Unimplemented,
+	503: codes.Unavailable,
+	504: codes.DeadlineExceeded,
+}
+
+// Обратное соответствие для статусов без упакованной цепочки
+var codeErrors = map[codes.Code]errx.Error{
+	codes.InvalidArgument:    errx.ErrBadRequest,
+	codes.Unauthenticated:    errx.ErrUnauthorized,
+	codes.PermissionDenied:   errx.ErrForbidden,
+	codes.NotFound:           errx.ErrNotFound,
+	codes.Aborted:            errx.ErrNotAllowed,
+	codes.OutOfRange:         errx.ErrNotAcceptable,
+	codes.FailedPrecondition: errx.ErrUnprocessable,
+	codes.Internal:           errx.ErrInternal,
+	codes.Unimplemented:      errx.ErrNotImplemented,
+	codes.Unavailable:        errx.ErrUnavailable,
+}
+
+var explicit = struct {
+	sync.RWMutex
+	list []mapping
+}{}
+
+type mapping struct {
+	err  errx.Error
+	code codes.Code
+}
+
+// Register - явный код gRPC для ошибки и всех ее наследников, важнее кода по HTTP-статусу.
+// Если подходит несколько зарегистрированных ошибок, побеждает самый дальний наследник, а не первая регистрация.
+func Register(err errx.Error, code codes.Code) {
+	explicit.Lock()
+	defer explicit.Unlock()
+	explicit.list = append(explicit.list, mapping{err: err, code: code})
+}
+
+// Code - код gRPC ошибки: явный из Register, затем код статуса gRPC, затем по контексту, затем по errx.Status, иначе Unknown
+func Code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	explicit.RLock()
+	var best *mapping
+	for i := range explicit.list {
+		if m := &explicit.list[i]; errx.Is(err, m.err) && (best == nil || errx.Is(m.err, best.err)) {
+			best = m
+		}
+	}
+	explicit.RUnlock()
+
+	if best != nil {
+		return best.code
+	}
+
+	if st, ok := status.FromError(err); ok && !isErrx(err) {
+		return st.Code()
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	}
+
+	if code, ok := httpCodes[errx.Status(err)]; ok {
+		return code
+	}
+
+	if st := errx.Status(err); st >= 500 {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// Status - статус gRPC по публичной проекции: текст первого публичного слоя,
+// в деталях упакован только этот слой (errx.PublicError) без стека, отладки и внутренних причин
+func Status(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	if st, ok := status.FromError(err); ok && !isErrx(err) {
+		return st
+	}
+
+	pb := status.New(Code(err), errx.Public(err).Message).Proto()
+	pb.Details = append(pb.Details, &anypb.Any{TypeUrl: PackedTypeURL, Value: errx.PublicError(err).Pack()})
+	return status.FromProto(pb)
+}
+
+// InternalStatus - статус gRPC со всей упакованной цепочкой errx в деталях, только для доверенных сервисов
+func InternalStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	if st, ok := status.FromError(err); ok && !isErrx(err) {
+		return st
+	}
+
+	pb := status.New(Code(err), err.Error()).Proto()
+
+	if e, ok := err.(errx.Error); ok {
+		pb.Details = append(pb.Details, &anypb.Any{TypeUrl: PackedTypeURL, Value: e.Pack()})
+	}
+
+	return status.FromProto(pb)
+}
+
+// FromStatus - ошибка из статуса gRPC: исходная цепочка, если она упакована в деталях, иначе ошибка по коду.
+// Испорченная деталь пропускается, статус приходит из сети и не должен ронять клиента.
+func FromStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	for _, d := range st.Proto().GetDetails() {
+		if d.GetTypeUrl() != PackedTypeURL {
+			continue
+		}
+
+		if err, exc := errx.ParsePacked(d.GetValue()); exc == nil {
+			return err
+		}
+	}
+
+	switch st.Code() {
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	}
+
+	if err, ok := codeErrors[st.Code()]; ok {
+		return err.WithDetail("%s", st.Message())
+	}
+	return errx.ErrInternal.WithDetail("%s: %s", st.Code(), st.Message())
+}
+
+// FromError - ошибка клиента gRPC в виде errx, не-статусные ошибки возвращаются как есть
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return FromStatus(st)
+	}
+	return err
+}
+
+func isErrx(err error) bool {
+	_, ok := err.(errx.Error)
+	return ok
+}
@@ -0,0 +1,16 @@
+//go:build errxdev
+
+package errxhttp
+
+import (
+	"io"
+	"net/http"
+)
+
+// DevBuild - сборка с тегом errxdev, в ней работает WithDevPage
+const DevBuild = true
+
+// writeDevPage - страница разработчика для Handler
+func writeDevPage(w io.Writer, r *http.Request, err error, opts PageOptions) error {
+	return WriteDevPage(w, r, err, opts)
+}
@@ -0,0 +1,259 @@
+package errxhttp_test
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fbs "github.com/google/flatbuffers/go"
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/errxhttp"
+	"github.com/stretchr/testify/suite"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталоны в testdata")
+
+func TestHTTP(t *testing.T) {
+	suite.Run(t, new(HTTPSuite))
+}
+
+type HTTPSuite struct {
+	suite.Suite
+}
+
+// golden - сравнение с эталоном из testdata, с флагом -update эталон перезаписывается
+func (s *HTTPSuite) golden(name string, got []byte) {
+	file := filepath.Join("testdata", name)
+
+	if *update {
+		s.Require().NoError(os.WriteFile(file, got, 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	s.Require().NoError(err)
+	s.Equal(string(want), string(got))
+}
+
+// sampleError - ошибка с фиксированным стеком, исходники кадров приложения лежат в testdata корня модуля
+func sampleError() errx.Error {
+	model := &errx.ErrorModelT{
+		Text:        "user not found",
+		Detail:      "id 42",
+		Fingerprint: "0123456789abcdef",
+		Stack: []string{
+			"../testdata/service.go:12 -> app.(*Service).Find()",
+			"../testdata/handler.go:17 -> app.(*Handler).ServeHTTP()",
+			"server.go:2220 -> http.HandlerFunc.ServeHTTP()",
+		},
+		FramePaths: []string{
+			"example.com/app@v1.2.3/service.go",
+			"example.com/app@v1.2.3/handler.go",
+			"",
+		},
+		Debug: []*errx.KeyValueT{
+			{Key: "query", Value: `SELECT * FROM users WHERE id = $1 AND "x" < 'y'`},
+			{Key: "user", Value: "42"},
+		},
+		Next: &errx.ErrorModelT{
+			Text:        "connection refused",
+			Fingerprint: "fedcba9876543210",
+			Stack:       []string{"conn.go:88 -> pgx.connect()"},
+		},
+	}
+
+	buf := fbs.NewBuilder(1024)
+	buf.Finish(model.Pack(buf))
+	return errx.Unpack(buf.FinishedBytes())
+}
+
+func sampleRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:8080/users/42?full=1", nil)
+	r.Header.Set("Accept", "text/html")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("User-Agent", "test")
+	return r
+}
+
+func (s *HTTPSuite) TestHandler() {
+	h := errxhttp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case "/ok":
+			_, err := w.Write([]byte("ok"))
+			return err
+		case "/missing":
+			return errx.ErrNotFound.WithDebug(errx.Debug{"user": 42})
+		}
+		return errors.New("boom")
+	})
+
+	for path, want := range map[string]struct {
+		code int
+		body string
+	}{
+		"/ok":      {http.StatusOK, "ok"},
+		"/missing": {http.StatusNotFound, errx.ErrNotFound.Error() + "\n"},
+		"/other":   {http.StatusInternalServerError, errx.ErrInternal.Error() + "\n"},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		s.Equal(want.code, w.Code, path)
+		s.Equal(want.body, w.Body.String(), path)
+	}
+}
+
+func (s *HTTPSuite) TestPublic() {
+	var logged error
+
+	h := errxhttp.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		cause := errx.New("select failed").WithDebug(errx.Debug{"query": "SELECT 1"})
+
+		switch r.URL.Path {
+		case "/internal":
+			return errx.New("load order").WithReason(cause)
+		case "/hidden":
+			return errx.ErrNotFound.WithDetail("order %d", 7).WithPublic(false).WithReason(cause)
+		}
+		return errx.New("load order").WithReason(errx.ErrNotFound.WithDetail("order %d", 7).WithReason(cause))
+	}, errxhttp.OnError(func(_ *http.Request, err error) { logged = err }))
+
+	for path, want := range map[string]struct {
+		code int
+		body string
+	}{
+		"/internal": {http.StatusInternalServerError, errx.ErrInternal.Error() + "\n"},
+		"/hidden":   {http.StatusNotFound, errx.ErrNotFound.Error() + "\n"},
+		"/public":   {http.StatusNotFound, "order 7\n"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set(errxhttp.CorrelationHeader, "req-"+path[1:])
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		s.Equal(want.code, w.Code, path)
+		s.Equal(want.body, w.Body.String(), path)
+		s.Equal("req-"+path[1:], w.Header().Get(errxhttp.CorrelationHeader), path)
+		s.Equal("req-"+path[1:], errx.CorrelationID(logged), path)
+		s.NotContains(w.Body.String(), "select failed")
+	}
+
+	// Без заголовка идентификатор создается, у сторонних ошибок тоже
+	h = errxhttp.Handler(func(http.ResponseWriter, *http.Request) error { return errors.New("boom") },
+		errxhttp.OnError(func(_ *http.Request, err error) { logged = err }))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	s.Len(w.Header().Get(errxhttp.CorrelationHeader), 16)
+	s.Equal(w.Header().Get(errxhttp.CorrelationHeader), errx.CorrelationID(logged))
+	s.True(errors.Is(logged, errx.ErrInternal))
+
+	// Заголовок с чужими символами или слишком длинный заменяется новым идентификатором
+	for _, bad := range []string{"req 1\r\nX-Evil: 1", "<script>", strings.Repeat("a", 65)} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(errxhttp.CorrelationHeader, bad)
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		s.Len(w.Header().Get(errxhttp.CorrelationHeader), 16, bad)
+		s.Equal(w.Header().Get(errxhttp.CorrelationHeader), errx.CorrelationID(logged), bad)
+	}
+}
+
+func (s *HTTPSuite) TestLocales() {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	s.Empty(errxhttp.Locales(r))
+
+	r.Header.Set("Accept-Language", "de;q=0.5, ru-RU, en;q=0.8, *;q=0.1, fr;q=0, bad;q=x")
+	s.Equal([]string{"ru-RU", "en", "de"}, errxhttp.Locales(r))
+}
+
+func (s *HTTPSuite) TestLocalized() {
+	catalog := errx.NewCatalog()
+	s.Require().NoError(catalog.LoadJSON("en", []byte(`{"user.missing": "User {id} not found"}`)))
+	s.Require().NoError(catalog.LoadJSON("ru", []byte(`{"user.missing": "Пользователь {id} не найден"}`)))
+
+	errx.SetLocaleOptions(errx.LocaleOptions{Catalog: catalog})
+	defer errx.SetLocaleOptions(errx.LocaleOptions{})
+
+	h := errxhttp.Handler(func(http.ResponseWriter, *http.Request) error {
+		return errx.New("load user").WithReason(errx.ErrNotFound.WithMessage("user.missing", errx.P{"id": 42}))
+	})
+
+	for lang, want := range map[string]string{
+		"":                "User 42 not found\n",
+		"ru-RU,ru;q=0.9":  "Пользователь 42 не найден\n",
+		"de-DE, de;q=0.9": "User 42 not found\n",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", lang)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		s.Equal(http.StatusNotFound, w.Code)
+		s.Equal(want, w.Body.String(), lang)
+		s.Equal("Accept, Accept-Language", w.Header().Get("Vary"))
+	}
+}
+
+func (s *HTTPSuite) TestProblem() {
+	h := errxhttp.Handler(func(http.ResponseWriter, *http.Request) error {
+		item := errx.NewValidationError().Add("/qty", "min", "must be > {min}", errx.P{"min": 0})
+		return errx.New("create order").WithReason(errx.NewValidationError().
+			Add("/email", "invalid_format", "invalid format", nil).
+			Merge(errx.Pointer("items", 2), item.Err()).
+			Err())
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set("Accept", "application/json, text/plain;q=0.5")
+	r.Header.Set(errxhttp.CorrelationHeader, "req-1")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	s.Equal(http.StatusUnprocessableEntity, w.Code)
+	s.Equal(errxhttp.ProblemType, w.Header().Get("Content-Type"))
+	s.JSONEq(`{
+		"title": "Unprocessable Entity",
+		"status": 422,
+		"correlation_id": "req-1",
+		"errors": [
+			{"pointer": "/email", "code": "invalid_format", "detail": "invalid format"},
+			{"pointer": "/items/2/qty", "code": "min", "detail": "must be > 0", "params": {"min": "0"}}
+		]
+	}`, w.Body.String())
+
+	// Без JSON в Accept - текст, нарушения по одному на строке
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", nil))
+	s.Equal(http.StatusUnprocessableEntity, w.Code)
+	s.Equal("422 Unprocessable Entity\nemail: invalid format\nitems[2].qty: must be > 0\n", w.Body.String())
+
+	// Обычная ошибка в problem+json без расширения "errors"
+	s.Equal(&errxhttp.Problem{Title: "Not Found", Status: http.StatusNotFound, Detail: "order 7"},
+		errxhttp.NewProblem(errx.ErrNotFound.WithDetail("order %d", 7)))
+	s.Equal(&errxhttp.Problem{Title: "Internal Server Error", Status: http.StatusInternalServerError},
+		errxhttp.NewProblem(errx.New("boom")))
+}
+
+func (s *HTTPSuite) TestDevOption() {
+	h := errxhttp.Handler(func(http.ResponseWriter, *http.Request) error {
+		return sampleError()
+	}, errxhttp.WithDevPage(errxhttp.PageOptions{}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, sampleRequest())
+	s.Equal(http.StatusInternalServerError, w.Code)
+
+	// Без тега errxdev страница не отдается никогда
+	if errxhttp.DevBuild {
+		s.Equal("text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		s.Contains(w.Body.String(), "<!DOCTYPE html>")
+	} else {
+		s.Equal(errx.ErrInternal.Error()+"\n", w.Body.String())
+	}
+}
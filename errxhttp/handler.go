@@ -0,0 +1,130 @@
+package errxhttp
+
+import (
+	"net/http"
+
+	"github.com/shestakovda/errx"
+)
+
+// CorrelationHeader - заголовок с идентификатором корреляции в запросе и в ответе об ошибке
+const CorrelationHeader = "X-Correlation-Id"
+
+// HandlerFunc - обработчик, возвращающий ошибку вместо самостоятельной записи ответа
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Option - настройка Handler
+type Option func(*config)
+
+type config struct {
+	dev     bool
+	page    PageOptions
+	onError func(r *http.Request, err error)
+}
+
+// PageOptions - настройки страницы разработчика
+type PageOptions struct {
+	Source  int      // Строк исходника до и после кадров модулей приложения, по умолчанию 5, меньше 0 - без исходников
+	Modules []string // Модули приложения, по умолчанию главный модуль сборки
+}
+
+// WithDevPage - отдавать страницу разработчика со всей цепочкой ошибки вместо обычного ответа.
+// Действует только в сборке с тегом errxdev (DevBuild), иначе игнорируется.
+func WithDevPage(opts PageOptions) Option {
+	return func(c *config) {
+		c.dev = DevBuild
+		c.page = opts
+	}
+}
+
+// OnError - вызов для каждой ошибки перед ответом, например для записи в лог.
+// У ошибки уже есть идентификатор корреляции, который получит клиент.
+func OnError(fn func(r *http.Request, err error)) Option {
+	return func(c *config) {
+		c.onError = fn
+	}
+}
+
+// Handler - http.Handler из обработчика с ошибкой, ответ об ошибке пишет WriteError,
+// а если клиент принимает JSON, то WriteProblem.
+// Ошибке без идентификатора корреляции назначается идентификатор из заголовка CorrelationHeader,
+// если он проходит errx.ValidCorrelationID, иначе новый.
+func Handler(fn HandlerFunc, opts ...Option) http.Handler {
+	var cfg config
+	for i := range opts {
+		opts[i](&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := fn(w, r)
+
+		if err == nil {
+			return
+		}
+
+		if errx.CorrelationID(err) == "" {
+			id := r.Header.Get(CorrelationHeader)
+			if !errx.ValidCorrelationID(id) {
+				id = ""
+			}
+			err = withCorrelation(err, id)
+		}
+
+		if cfg.onError != nil {
+			cfg.onError(r, err)
+		}
+
+		if cfg.dev {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(StatusCode(err))
+			_ = writeDevPage(w, r, err, cfg.page)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept, Accept-Language")
+
+		if acceptsProblem(r) {
+			WriteProblem(w, err, Locales(r)...)
+			return
+		}
+
+		WriteError(w, err, Locales(r)...)
+	})
+}
+
+// WriteError - ответ по публичной проекции errx.Public: детализация первого публичного слоя
+// в первой подходящей локали или, если ее нет, его текст, и нарушения проверки полей по одному на строке.
+// Внутренние слои, отладка и стек не выводятся.
+func WriteError(w http.ResponseWriter, err error, locales ...string) {
+	pub := errx.Public(err, locales...)
+
+	if pub.CorrelationID != "" {
+		w.Header().Set(CorrelationHeader, pub.CorrelationID)
+	}
+
+	text := pub.Detail
+	if text == "" {
+		text = pub.Message
+	}
+
+	for _, v := range pub.Violations {
+		text += "\n" + v.String()
+	}
+
+	http.Error(w, text, pub.Code)
+}
+
+// withCorrelation - копия ошибки с идентификатором корреляции, сторонние ошибки оборачиваются в ErrInternal
+func withCorrelation(err error, id string) error {
+	if e, ok := err.(errx.Error); ok {
+		return e.WithCorrelationID(id)
+	}
+	return errx.ErrInternal.WithReason(err).WithCorrelationID(id)
+}
+
+// StatusCode - код ответа по errx.Status, без него ошибка считается внутренней
+func StatusCode(err error) int {
+	if code := errx.Status(err); code != 0 {
+		return code
+	}
+	return http.StatusInternalServerError
+}
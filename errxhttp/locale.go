@@ -0,0 +1,45 @@
+package errxhttp
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locales - локали из Accept-Language по убыванию веса, без "*" и с нулевым весом
+func Locales(r *http.Request) []string {
+	type item struct {
+		tag string
+		q   float64
+	}
+
+	var list []item
+
+	for _, header := range r.Header.Values("Accept-Language") {
+		for _, part := range strings.Split(header, ",") {
+			tag, params, _ := strings.Cut(part, ";")
+			it := item{tag: strings.TrimSpace(tag), q: 1}
+
+			if name, val, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+				q, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+				if err != nil {
+					continue
+				}
+				it.q = q
+			}
+
+			if it.tag != "" && it.tag != "*" && it.q > 0 {
+				list = append(list, it)
+			}
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+
+	res := make([]string, len(list))
+	for i := range list {
+		res[i] = list[i].tag
+	}
+	return res
+}
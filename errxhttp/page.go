@@ -0,0 +1,204 @@
+//go:build errxdev
+
+package errxhttp
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/shestakovda/errx"
+)
+
+type pageData struct {
+	Status  int
+	Title   string
+	Method  string
+	URL     string
+	Layers  []pageLayer
+	Request []pageItem
+	Headers []pageItem
+}
+
+type pageLayer struct {
+	Text        string
+	Detail      string
+	Fingerprint string
+	Debug       []pageItem
+	Frames      []pageFrame
+}
+
+type pageFrame struct {
+	Text   string
+	Link   string
+	Module bool
+	Lines  []errx.SourceLine
+}
+
+type pageItem struct {
+	Key   string
+	Value string
+}
+
+// WriteDevPage - самодостаточная HTML страница с цепочкой ошибки и запросом.
+// Есть только в сборке с тегом errxdev: показывает отладку и исходники,
+// значения отладки и заголовки запроса скрываются по правилам errx.Redacted.
+func WriteDevPage(w io.Writer, r *http.Request, err error, opts PageOptions) error {
+	if opts.Source == 0 {
+		opts.Source = 5
+	}
+
+	if opts.Modules == nil {
+		opts.Modules = mainModules()
+	}
+
+	data := pageData{Status: StatusCode(err)}
+	data.Title = http.StatusText(data.Status)
+
+	for v := errx.ExportRedacted(err); v != nil; v = v.Next {
+		layer := pageLayer{Text: v.Text, Detail: v.Detail, Fingerprint: v.Fingerprint}
+
+		for _, key := range v.Keys() {
+			layer.Debug = append(layer.Debug, pageItem{key, v.Debug[key]})
+		}
+
+		frames := v.Frames
+		if frames == nil {
+			for i := range v.Stack {
+				frames = append(frames, errx.Frame{Func: v.Stack[i]})
+			}
+		}
+
+		for _, f := range frames {
+			item := pageFrame{Text: f.String(), Link: f.Link(), Module: f.InModule(opts.Modules...)}
+			if f.File == "" {
+				item.Text = f.Func
+			}
+
+			if item.Module && opts.Source > 0 {
+				item.Lines = errx.Snippet(f, opts.Source)
+			}
+			layer.Frames = append(layer.Frames, item)
+		}
+
+		data.Layers = append(data.Layers, layer)
+	}
+
+	if r != nil {
+		data.Method, data.URL = r.Method, r.URL.String()
+		data.Request = []pageItem{
+			{"Method", r.Method},
+			{"URL", r.URL.String()},
+			{"Proto", r.Proto},
+			{"Host", r.Host},
+			{"Remote", r.RemoteAddr},
+		}
+
+		keys := make([]string, 0, len(r.Header))
+		for key := range r.Header {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			val := strings.Join(r.Header[key], ", ")
+			if errx.Redacted(key) {
+				val = errx.RedactedValue
+			}
+			data.Headers = append(data.Headers, pageItem{key, val})
+		}
+	}
+
+	return pageTpl.Execute(w, data)
+}
+
+// mainModules - главный модуль сборки для выделения своих кадров
+func mainModules() []string {
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" {
+		return []string{bi.Main.Path}
+	}
+	return nil
+}
+
+var pageTpl = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Title}}</title>
+<style>
+body { font: 14px/1.5 -apple-system, "Segoe UI", sans-serif; margin: 0; padding: 24px; background: #f6f7f9; color: #1f2328; }
+h1 { font-size: 20px; margin: 0 0 16px; }
+h1 span { color: #cf222e; }
+h2 { font-size: 15px; margin: 24px 0 8px; }
+details { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; margin: 0 0 12px; }
+summary { cursor: pointer; padding: 10px 14px; font-weight: 600; }
+summary .detail { font-weight: 400; color: #57606a; }
+.body { padding: 0 14px 12px; }
+.fp { color: #8c959f; font: 12px monospace; }
+table { border-collapse: collapse; width: 100%; margin: 8px 0; }
+td { border-top: 1px solid #eaeef2; padding: 4px 8px; vertical-align: top; font: 13px monospace; word-break: break-all; }
+td.key { width: 20%; color: #0550ae; }
+ol { margin: 8px 0; padding-left: 24px; font: 13px monospace; }
+li { color: #8c959f; }
+li.module { color: #1f2328; font-weight: 600; }
+a { color: inherit; }
+pre { margin: 4px 0 8px; padding: 6px 0; background: #f6f8fa; border-radius: 4px; font-weight: 400; overflow-x: auto; }
+pre span { display: block; padding: 0 8px; color: #57606a; }
+pre span.focus { background: #ffebe9; color: #1f2328; }
+</style>
+</head>
+<body>
+<h1><span>{{.Status}}</span> {{.Title}}</h1>
+{{range $i, $l := .Layers -}}
+<details open>
+<summary>{{if $i}}caused by: {{end}}{{$l.Text}}{{if $l.Detail}} <span class="detail">({{$l.Detail}})</span>{{end}}</summary>
+<div class="body">
+{{- if $l.Fingerprint}}
+<div class="fp">fingerprint {{$l.Fingerprint}}</div>
+{{- end}}
+{{- if $l.Debug}}
+<table>
+{{- range $l.Debug}}
+<tr><td class="key">{{.Key}}</td><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if $l.Frames}}
+<ol>
+{{- range $l.Frames}}
+<li{{if .Module}} class="module"{{end}}>{{if .Link}}<a href="{{.Link}}">{{.Text}}</a>{{else}}{{.Text}}{{end}}
+{{- if .Lines}}
+<pre>{{range .Lines}}<span{{if .Focus}} class="focus"{{end}}>{{printf "%4d" .Number}}  {{.Text}}</span>{{end}}</pre>
+{{- end}}</li>
+{{- end}}
+</ol>
+{{- end}}
+</div>
+</details>
+{{end -}}
+{{if .Request -}}
+<h2>Request</h2>
+<details open>
+<summary>{{.Method}} {{.URL}}</summary>
+<div class="body">
+<table>
+{{- range .Request}}
+<tr><td class="key">{{.Key}}</td><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- if .Headers}}
+<table>
+{{- range .Headers}}
+<tr><td class="key">{{.Key}}</td><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</div>
+</details>
+{{end -}}
+</body>
+</html>
+`))
@@ -0,0 +1,31 @@
+//go:build errxdev
+
+package errxhttp_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/errxhttp"
+)
+
+func (s *HTTPSuite) TestDevPage() {
+	errx.SetLinkOptions(errx.LinkOptions{Template: "https://git.example.com/{module}/blob/{version}/{path}#L{line}"})
+	defer errx.SetLinkOptions(errx.LinkOptions{})
+
+	var buf bytes.Buffer
+	opts := errxhttp.PageOptions{Source: 2, Modules: []string{"example.com/app"}}
+	s.Require().NoError(errxhttp.WriteDevPage(&buf, sampleRequest(), sampleError(), opts))
+	s.golden("devpage.golden.html", buf.Bytes())
+
+	buf.Reset()
+	s.Require().NoError(errxhttp.WriteDevPage(&buf, nil, errors.New("plain <error>"), opts))
+	s.golden("devpage_plain.golden.html", buf.Bytes())
+
+	// Чувствительные значения отладки скрываются, как и заголовки запроса
+	buf.Reset()
+	s.Require().NoError(errxhttp.WriteDevPage(&buf, nil, errx.New("login failed").WithDebug(errx.Debug{"password": "hunter2"}), opts))
+	s.NotContains(buf.String(), "hunter2")
+	s.Contains(buf.String(), errx.RedactedValue)
+}
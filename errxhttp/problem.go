@@ -0,0 +1,76 @@
+package errxhttp
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/shestakovda/errx"
+)
+
+// ProblemType - тип содержимого ответа по RFC 9457
+const ProblemType = "application/problem+json"
+
+// Problem - ответ об ошибке в формате problem+json, нарушения проверки полей в расширении "errors"
+type Problem struct {
+	Type          string         `json:"type,omitempty"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	CorrelationID string         `json:"correlation_id,omitempty"`
+	Errors        []ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField - нарушение в одном поле запроса
+type ProblemField struct {
+	Pointer string            `json:"pointer"`
+	Code    string            `json:"code,omitempty"`
+	Detail  string            `json:"detail"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+// NewProblem - problem+json по публичной проекции errx.Public, сообщения в первой подходящей локали.
+// Заголовок - текст публичного слоя без HTTP-статуса, тип не указывается, то есть "about:blank".
+func NewProblem(err error, locales ...string) *Problem {
+	pub := errx.Public(err, locales...)
+
+	p := &Problem{
+		Title:         strings.TrimPrefix(pub.Message, strconv.Itoa(pub.Code)+" "),
+		Status:        pub.Code,
+		Detail:        pub.Detail,
+		CorrelationID: pub.CorrelationID,
+	}
+
+	for _, v := range pub.Violations {
+		p.Errors = append(p.Errors, ProblemField{Pointer: v.Path, Code: v.Code, Detail: v.Message, Params: v.Params})
+	}
+	return p
+}
+
+// WriteProblem - ответ об ошибке в формате problem+json
+func WriteProblem(w http.ResponseWriter, err error, locales ...string) {
+	p := NewProblem(err, locales...)
+
+	if p.CorrelationID != "" {
+		w.Header().Set(CorrelationHeader, p.CorrelationID)
+	}
+
+	w.Header().Set("Content-Type", ProblemType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// acceptsProblem - просит ли клиент JSON в заголовке Accept
+func acceptsProblem(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(header, ",") {
+			if typ, _, err := mime.ParseMediaType(part); err == nil && (typ == ProblemType || typ == "application/json") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+//go:build !errxdev
+
+package errxhttp
+
+import (
+	"io"
+	"net/http"
+)
+
+// DevBuild - сборка с тегом errxdev, в ней работает WithDevPage
+const DevBuild = false
+
+// writeDevPage - без тега errxdev страницы разработчика нет, сюда Handler не доходит
+func writeDevPage(io.Writer, *http.Request, error, PageOptions) error { return nil }
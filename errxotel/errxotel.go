@@ -0,0 +1,142 @@
+package errxotel
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shestakovda/errx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+)
+
+// Атрибуты событий, кроме стандартных exception.*
+const (
+	SentinelKey    = attribute.Key("errx.sentinel")    // Ближайшая зарегистрированная ошибка: "пакет:текст"
+	DetailKey      = attribute.Key("errx.detail")      // Детализация для пользователя
+	FingerprintKey = attribute.Key("errx.fingerprint") // Отпечаток цепочки от этого слоя
+	DebugPrefix    = "errx.debug."                     // Префикс ключей отладки, значения скрываются через errx.Redact
+)
+
+// Option - настройка записи ошибки
+type Option func(*config)
+
+type config struct {
+	kind trace.SpanKind
+	rpc  bool
+	code grpccodes.Code
+}
+
+// WithSpanKind - вид спана для выбора статуса: у серверных спанов ошибки клиента (4xx) не считаются ошибкой
+func WithSpanKind(kind trace.SpanKind) Option {
+	return func(c *config) { c.kind = kind }
+}
+
+// WithGRPC - спан вызова gRPC с кодом ответа, например по errxgrpc.Code, в атрибуте rpc.grpc.status_code.
+// У серверных спанов ошибкой считаются только Unknown, DeadlineExceeded, Unimplemented, Internal, Unavailable и DataLoss
+func WithGRPC(code grpccodes.Code) Option {
+	return func(c *config) { c.rpc, c.code = true, code }
+}
+
+// RecordError - запись ошибки в спан: каждый слой цепочки отдельным событием "exception", начиная с внешнего.
+// Цепочки errx внутри сторонних оберток (fmt.Errorf с %w) записываются полностью.
+// Статус спана выставляется по errx.Status или коду gRPC (WithGRPC), без статуса ошибка считается внутренней.
+// Значения отладки скрываются по правилам errx.Redact.
+func RecordError(span trace.Span, err error, opts ...Option) {
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	cfg := config{kind: trace.SpanKindInternal}
+	for i := range opts {
+		opts[i](&cfg)
+	}
+
+	status := errx.Status(err)
+	cur := err
+
+	for v := errx.ExportRedacted(err); v != nil; v = v.Next {
+		attrs := layer(cur, v)
+
+		if cur == err && cfg.rpc {
+			attrs = append(attrs, semconv.RPCGRPCStatusCodeKey.Int(int(cfg.code)))
+		} else if cur == err && status != 0 {
+			attrs = append(attrs, semconv.HTTPResponseStatusCode(status))
+		}
+
+		span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(attrs...))
+
+		if cur != nil {
+			cur = errors.Unwrap(cur)
+		}
+	}
+
+	if cfg.kind == trace.SpanKindServer {
+		if (cfg.rpc && !serverFault[cfg.code]) || (!cfg.rpc && status > 0 && status < 500) {
+			return
+		}
+	}
+
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// serverFault - коды gRPC, которые у серверного спана означают ошибку сервера, а не клиента
+var serverFault = map[grpccodes.Code]bool{
+	grpccodes.Unknown:          true,
+	grpccodes.DeadlineExceeded: true,
+	grpccodes.Unimplemented:    true,
+	grpccodes.Internal:         true,
+	grpccodes.Unavailable:      true,
+	grpccodes.DataLoss:         true,
+}
+
+func layer(err error, v *errx.View) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 6+len(v.Debug))
+	attrs = append(attrs, semconv.ExceptionType(typeName(err)), semconv.ExceptionMessage(v.Text))
+
+	if len(v.Stack) > 0 {
+		attrs = append(attrs, semconv.ExceptionStacktrace(strings.Join(v.Stack, "\n")))
+	}
+
+	if _, ok := err.(errx.Error); !ok {
+		return attrs
+	}
+
+	if p, org, ok := errx.Sentinel(err); ok {
+		attrs = append(attrs, SentinelKey.String(org.Package+":"+p.Error()))
+	}
+
+	if v.Detail != "" {
+		attrs = append(attrs, DetailKey.String(v.Detail))
+	}
+
+	if v.Fingerprint != "" {
+		attrs = append(attrs, FingerprintKey.String(v.Fingerprint))
+	}
+
+	keys := make([]string, 0, len(v.Debug))
+	for key := range v.Debug {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		attrs = append(attrs, attribute.String(DebugPrefix+key, v.Debug[key]))
+	}
+	return attrs
+}
+
+func typeName(err error) string {
+	if err == nil {
+		return "error"
+	}
+
+	if _, ok := err.(errx.Error); ok {
+		return "errx.Error"
+	}
+	return fmt.Sprintf("%T", err)
+}
@@ -0,0 +1,149 @@
+package errxotel_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/errxotel"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+)
+
+func TestOtel(t *testing.T) {
+	suite.Run(t, new(OtelSuite))
+}
+
+type OtelSuite struct {
+	suite.Suite
+	exp *tracetest.InMemoryExporter
+	tp  *sdktrace.TracerProvider
+}
+
+func (s *OtelSuite) SetupTest() {
+	s.exp = tracetest.NewInMemoryExporter()
+	s.tp = sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.exp))
+}
+
+func (s *OtelSuite) record(err error, opts ...errxotel.Option) tracetest.SpanStub {
+	_, span := s.tp.Tracer("test").Start(context.Background(), "op")
+	errxotel.RecordError(span, err, opts...)
+	span.End()
+
+	list := s.exp.GetSpans()
+	s.Require().Len(list, 1)
+	return list[0]
+}
+
+func attrs(list []attribute.KeyValue) map[string]string {
+	res := make(map[string]string, len(list))
+	for _, kv := range list {
+		res[string(kv.Key)] = kv.Value.Emit()
+	}
+	return res
+}
+
+func (s *OtelSuite) TestChain() {
+	err := errx.ErrNotFound.
+		WithDetail("user %d", 42).
+		WithDebug(errx.Debug{"id": 42, "token": "secret"}).
+		WithReason(errx.New("db miss").WithReason(io.EOF))
+
+	span := s.record(err)
+
+	s.Equal(codes.Error, span.Status.Code)
+	s.Equal("404 Not Found", span.Status.Description)
+
+	if s.Len(span.Events, 3) {
+		top := attrs(span.Events[0].Attributes)
+		s.Equal("exception", span.Events[0].Name)
+		s.Equal("errx.Error", top["exception.type"])
+		s.Equal("404 Not Found", top["exception.message"])
+		s.Contains(top["exception.stacktrace"], "errxotel_test.(*OtelSuite).TestChain()")
+		s.Equal("github.com/shestakovda/errx:404 Not Found", top["errx.sentinel"])
+		s.Equal("user 42", top["errx.detail"])
+		s.Equal(errx.Fingerprint(err), top["errx.fingerprint"])
+		s.Equal("42", top["errx.debug.id"])
+		s.Equal(errx.RedactedValue, top["errx.debug.token"])
+		s.Equal("404", top["http.response.status_code"])
+
+		mid := attrs(span.Events[1].Attributes)
+		s.Equal("db miss", mid["exception.message"])
+		s.Empty(mid["errx.sentinel"])
+		s.Empty(mid["http.response.status_code"])
+
+		low := attrs(span.Events[2].Attributes)
+		s.Equal("*errors.errorString", low["exception.type"])
+		s.Equal("EOF", low["exception.message"])
+		s.Empty(low["exception.stacktrace"])
+	}
+}
+
+func (s *OtelSuite) TestStatus() {
+	span := s.record(errx.ErrNotFound.WithStack(), errxotel.WithSpanKind(trace.SpanKindServer))
+	s.Equal(codes.Unset, span.Status.Code)
+	s.Len(span.Events, 1)
+
+	s.exp.Reset()
+	span = s.record(errx.ErrUnavailable.WithStack(), errxotel.WithSpanKind(trace.SpanKindServer))
+	s.Equal(codes.Error, span.Status.Code)
+
+	s.exp.Reset()
+	span = s.record(io.EOF, errxotel.WithSpanKind(trace.SpanKindServer))
+	s.Equal(codes.Error, span.Status.Code)
+
+	s.exp.Reset()
+	span = s.record(nil)
+	s.Equal(codes.Unset, span.Status.Code)
+	s.Empty(span.Events)
+}
+
+func (s *OtelSuite) TestWrapped() {
+	err := fmt.Errorf("handle: %w", errx.ErrNotFound.WithDetail("user %d", 42).WithReason(io.EOF))
+	span := s.record(err)
+
+	if s.Len(span.Events, 3) {
+		top := attrs(span.Events[0].Attributes)
+		s.Equal("*fmt.wrapError", top["exception.type"])
+		s.Equal("404", top["http.response.status_code"])
+
+		mid := attrs(span.Events[1].Attributes)
+		s.Equal("errx.Error", mid["exception.type"])
+		s.Equal("user 42", mid["errx.detail"])
+		s.Equal("github.com/shestakovda/errx:404 Not Found", mid["errx.sentinel"])
+
+		s.Equal("EOF", attrs(span.Events[2].Attributes)["exception.message"])
+	}
+}
+
+func (s *OtelSuite) TestGRPC() {
+	span := s.record(errx.ErrNotFound.WithStack(), errxotel.WithGRPC(grpccodes.NotFound), errxotel.WithSpanKind(trace.SpanKindServer))
+	s.Equal(codes.Unset, span.Status.Code)
+
+	if s.Len(span.Events, 1) {
+		top := attrs(span.Events[0].Attributes)
+		s.Equal("5", top["rpc.grpc.status_code"])
+		s.Empty(top["http.response.status_code"])
+	}
+
+	s.exp.Reset()
+	span = s.record(errx.ErrUnavailable.WithStack(), errxotel.WithGRPC(grpccodes.Unavailable), errxotel.WithSpanKind(trace.SpanKindServer))
+	s.Equal(codes.Error, span.Status.Code)
+
+	s.exp.Reset()
+	span = s.record(context.DeadlineExceeded, errxotel.WithGRPC(grpccodes.DeadlineExceeded), errxotel.WithSpanKind(trace.SpanKindServer))
+	s.Equal(codes.Error, span.Status.Code)
+	s.Equal("4", attrs(span.Events[0].Attributes)["rpc.grpc.status_code"])
+
+	// У клиента ошибкой считается любой код, кроме OK
+	s.exp.Reset()
+	span = s.record(errx.ErrNotFound.WithStack(), errxotel.WithGRPC(grpccodes.NotFound), errxotel.WithSpanKind(trace.SpanKindClient))
+	s.Equal(codes.Error, span.Status.Code)
+}
@@ -0,0 +1,228 @@
+package errxsentry_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/errxsentry"
+	"github.com/shestakovda/errx/report"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSentry(t *testing.T) {
+	suite.Run(t, new(SentrySuite))
+}
+
+type SentrySuite struct {
+	suite.Suite
+}
+
+func (s *SentrySuite) opts() errxsentry.Options {
+	return errxsentry.Options{
+		Release: "1.2.3",
+		Tags:    map[string]string{"svc": "test"},
+		Now:     func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+}
+
+func findFrame(st *errxsentry.Stacktrace, fn string) errxsentry.Frame {
+	for _, f := range st.Frames {
+		if f.Function == fn {
+			return f
+		}
+	}
+	return errxsentry.Frame{}
+}
+
+func (s *SentrySuite) TestEncode() {
+	err := errx.ErrNotFound.
+		WithDetail("user %d", 42).
+		WithDebug(errx.Debug{"id": 42, "password": "qwerty"}).
+		WithReason(errx.New("db miss").WithDebug(errx.Debug{"table": "users"}).WithReason(io.EOF))
+
+	ev := errxsentry.NewEvent(err, s.opts())
+
+	s.Len(ev.EventID, 32)
+	s.Equal("2020-01-02T03:04:05Z", ev.Timestamp)
+	s.Equal("go", ev.Platform)
+	s.Equal("error", ev.Level)
+	s.Equal("1.2.3", ev.Release)
+	s.Equal([]string{errx.Fingerprint(err)}, ev.Fingerprint)
+	s.Equal(map[string]string{
+		"id":       "42",
+		"password": errx.RedactedValue,
+		"1.table":  `"users"`,
+	}, ev.Extra)
+
+	if list := ev.Exception.Values; s.Len(list, 3) {
+		s.Equal(errxsentry.Exception{Type: "EOF", Value: "EOF"}, list[0])
+		s.Equal("db miss", list[1].Type)
+		s.Equal("404 Not Found", list[2].Type)
+		s.Equal("user 42", list[2].Value)
+
+		if st := list[2].Stacktrace; s.NotNil(st) {
+			last := findFrame(st, "(*SentrySuite).TestEncode")
+			s.Equal("github.com/shestakovda/errx/errxsentry_test", last.Module)
+			s.Equal("errxsentry_test.go", last.Filename)
+			s.True(strings.HasSuffix(last.AbsPath, "/errxsentry/errxsentry_test.go"))
+			s.True(last.InApp)
+			s.False(st.Frames[0].InApp)
+		}
+	}
+
+	// После Unpack пакеты известны только по имени, полный путь восстанавливается по модулю кадра
+	opts := s.opts()
+	opts.InApp = []string{"github.com/shestakovda/errx/errxsentry"}
+	ev = errxsentry.NewEvent(errx.Unpack(err.Pack()), opts)
+	if st := ev.Exception.Values[2].Stacktrace; s.NotNil(st) {
+		last := findFrame(st, "(*SentrySuite).TestEncode")
+		s.Equal("errxsentry_test", last.Module)
+		s.True(last.InApp)
+	}
+
+	// Пакет с тем же именем из другого модуля к приложению не относится
+	opts.InApp = []string{"example.com/other/errxsentry"}
+	ev = errxsentry.NewEvent(errx.Unpack(err.Pack()), opts)
+	if st := ev.Exception.Values[2].Stacktrace; s.NotNil(st) {
+		s.False(findFrame(st, "(*SentrySuite).TestEncode").InApp)
+	}
+
+	// Сторонняя обертка не скрывает цепочку errx
+	ev = errxsentry.NewEvent(fmt.Errorf("handle: %w", err), s.opts())
+	if list := ev.Exception.Values; s.Len(list, 4) {
+		s.Equal("404 Not Found", list[2].Type)
+		s.Equal("user 42", list[2].Value)
+	}
+
+	s.Nil(errxsentry.NewEvent(nil, s.opts()))
+	s.Nil(errxsentry.Encode(nil, s.opts()))
+}
+
+func (s *SentrySuite) TestDSN() {
+	_, err := errxsentry.ParseDSN("https://example.com/1")
+	s.True(errx.Is(err, errxsentry.ErrDSN))
+
+	_, err = errxsentry.ParseDSN("https://key@example.com/")
+	s.True(errx.Is(err, errxsentry.ErrDSN))
+
+	dsn, err := errxsentry.ParseDSN("https://key@example.com/sentry/42")
+	s.Require().NoError(err)
+	s.Equal("https://example.com/sentry/api/42/envelope/", dsn.EnvelopeURL())
+	s.Equal("Sentry sentry_version=7, sentry_client=errx-sentry/1.0, sentry_key=key", dsn.AuthHeader())
+}
+
+func (s *SentrySuite) TestSend() {
+	var got []*http.Request
+	var body [][]byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		got = append(got, r)
+		body = append(body, buf)
+
+		if len(got) > 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := errxsentry.NewClient(strings.Replace(srv.URL, "://", "://pub@", 1)+"/7", s.opts())
+	s.Require().NoError(err)
+
+	exp := errx.ErrInternal.WithReason(io.EOF)
+	id, err := cli.Capture(context.Background(), exp)
+	s.Require().NoError(err)
+
+	if s.Len(got, 1) {
+		s.Equal("/api/7/envelope/", got[0].URL.Path)
+		s.Equal("application/x-sentry-envelope", got[0].Header.Get("Content-Type"))
+		s.Contains(got[0].Header.Get("X-Sentry-Auth"), "sentry_key=pub")
+
+		scan := bufio.NewScanner(bytes.NewReader(body[0]))
+		head := make(map[string]string)
+		item := make(map[string]interface{})
+		ev := new(errxsentry.Event)
+
+		s.True(scan.Scan())
+		s.NoError(json.Unmarshal(scan.Bytes(), &head))
+		s.True(scan.Scan())
+		s.NoError(json.Unmarshal(scan.Bytes(), &item))
+		s.True(scan.Scan())
+		s.NoError(json.Unmarshal(scan.Bytes(), ev))
+
+		s.Equal(id, head["event_id"])
+		s.Equal("event", item["type"])
+		s.Equal(float64(len(scan.Bytes())), item["length"])
+		s.Equal(id, ev.EventID)
+		s.Equal("500 Internal Server Error", ev.Exception.Values[1].Type)
+		s.Equal(map[string]string{"svc": "test"}, ev.Tags)
+	}
+
+	// Как приемник Reporter
+	rep := report.New(report.Options{Sinks: []report.Sink{cli}})
+	err = rep.Report(exp)
+	s.True(errx.Is(err, errxsentry.ErrSend))
+	s.True(errx.IsRetryable(err))
+	s.Equal("429 Too Many Requests", err.(errx.Error).Export().Detail)
+}
+
+func (s *SentrySuite) TestRetryAfter() {
+	calls := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	dsn, err := errxsentry.ParseDSN(strings.Replace(srv.URL, "://", "://pub@", 1) + "/7")
+	s.Require().NoError(err)
+
+	// Без своего http.Client используется стандартный
+	cli := &errxsentry.Client{DSN: dsn, Options: s.opts()}
+
+	id, err := cli.Capture(context.Background(), nil)
+	s.NoError(err)
+	s.Empty(id)
+	s.Zero(calls)
+
+	_, err = cli.Capture(context.Background(), errx.ErrInternal.WithStack())
+	s.True(errx.Is(err, errxsentry.ErrSend))
+	after, ok := errx.RetryAfter(err)
+	s.True(ok)
+	s.Equal(time.Minute, after)
+
+	// До конца паузы сервер не беспокоим
+	_, err = cli.Capture(context.Background(), errx.ErrInternal.WithStack())
+	s.True(errx.Is(err, errxsentry.ErrSend))
+	after, _ = errx.RetryAfter(err)
+	s.True(after > 0 && after <= time.Minute)
+	s.Equal(1, calls)
+	s.True(errx.IsRetryable(err))
+}
+
+func (s *SentrySuite) TestRejected() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	dsn, err := errxsentry.ParseDSN(strings.Replace(srv.URL, "://", "://pub@", 1) + "/7")
+	s.Require().NoError(err)
+
+	// Событие, которое сервер отверг, повторять бессмысленно
+	_, err = (&errxsentry.Client{DSN: dsn, Options: s.opts()}).Capture(context.Background(), errx.ErrInternal.WithStack())
+	s.True(errx.Is(err, errxsentry.ErrSend))
+	s.False(errx.IsRetryable(err))
+}
@@ -0,0 +1,162 @@
+package errxsentry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/shestakovda/errx"
+)
+
+// Event - событие Sentry с цепочкой исключений
+type Event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+	Exception   *ExceptionList    `json:"exception"`
+}
+
+// ExceptionList - исключения от самой глубокой причины к внешней ошибке, как принято в Sentry
+type ExceptionList struct {
+	Values []Exception `json:"values"`
+}
+
+// Exception - один слой цепочки
+type Exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+// Stacktrace - стек от самого раннего вызова к самому позднему
+type Stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame - кадр стека Sentry
+type Frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+// Options - параметры событий
+type Options struct {
+	InApp       []string          // Модули приложения для in_app, по умолчанию главный модуль сборки
+	Release     string            // Версия приложения
+	Environment string            // Окружение: production, staging и т.п.
+	ServerName  string            // Имя хоста
+	Tags        map[string]string // Общие теги всех событий
+	Now         func() time.Time  // Часы, по умолчанию time.Now
+}
+
+func (o Options) withDefaults() Options {
+	if o.InApp == nil {
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" {
+			o.InApp = []string{bi.Main.Path}
+		}
+	}
+
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// NewEvent - событие по ошибке, значения отладки скрываются по правилам errx.Redact, для nil - nil
+func NewEvent(err error, opts Options) *Event {
+	if err == nil {
+		return nil
+	}
+	return Encode(errx.ExportRedacted(err), opts)
+}
+
+// Encode - преобразование представления ошибки в событие Sentry.
+// Каждый слой становится исключением с текстом в типе и детализацией в значении, кадры модулей opts.InApp
+// отмечаются как in_app. Отладка слоев собирается в extra, для причин ключ предваряется номером слоя.
+func Encode(v *errx.View, opts Options) *Event {
+	if v == nil {
+		return nil
+	}
+
+	opts = opts.withDefaults()
+
+	ev := &Event{
+		EventID:     newEventID(),
+		Timestamp:   opts.Now().UTC().Format(time.RFC3339Nano),
+		Platform:    "go",
+		Level:       "error",
+		Release:     opts.Release,
+		Environment: opts.Environment,
+		ServerName:  opts.ServerName,
+		Tags:        opts.Tags,
+		Exception:   new(ExceptionList),
+	}
+
+	if v.Fingerprint != "" {
+		ev.Fingerprint = []string{v.Fingerprint}
+	}
+
+	for i := 0; v != nil; i, v = i+1, v.Next {
+		exc := Exception{Type: v.Text, Value: v.Detail}
+
+		if exc.Value == "" {
+			exc.Value = v.Text
+		}
+
+		if len(v.Frames) > 0 {
+			exc.Stacktrace = &Stacktrace{Frames: make([]Frame, 0, len(v.Frames))}
+			for j := len(v.Frames) - 1; j >= 0; j-- {
+				exc.Stacktrace.Frames = append(exc.Stacktrace.Frames, newFrame(v.Frames[j], opts.InApp))
+			}
+		}
+
+		for key, val := range v.Debug {
+			if ev.Extra == nil {
+				ev.Extra = make(map[string]string)
+			}
+
+			if i > 0 {
+				key = fmt.Sprintf("%d.%s", i, key)
+			}
+			ev.Extra[key] = val
+		}
+
+		ev.Exception.Values = append([]Exception{exc}, ev.Exception.Values...)
+	}
+
+	return ev
+}
+
+func newFrame(f errx.Frame, modules []string) Frame {
+	pkg := f.Package()
+
+	return Frame{
+		Function: strings.TrimPrefix(f.Func, pkg+"."),
+		Module:   pkg,
+		Filename: path.Base(f.File),
+		AbsPath:  f.File,
+		Lineno:   f.Line,
+		InApp:    f.InModule(modules...),
+	}
+}
+
+func newEventID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,209 @@
+package errxsentry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/report"
+)
+
+// ErrDSN - некорректный DSN
+var ErrDSN = errx.Define("invalid sentry DSN")
+
+// ErrSend - сервер не принял событие
+var ErrSend = errx.Register(errx.ErrUnavailable.Derive("sentry event not accepted"))
+
+const userAgent = "errx-sentry/1.0"
+
+// DSN - разобранный адрес проекта Sentry вида https://key@host/path/project
+type DSN struct {
+	raw     string
+	key     string
+	project string
+	base    url.URL
+}
+
+// ParseDSN - разбор адреса проекта
+func ParseDSN(raw string) (*DSN, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, ErrDSN.WithReason(err)
+	}
+
+	if u.User == nil || u.User.Username() == "" || u.Host == "" {
+		return nil, ErrDSN.WithDetail("%s", raw)
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndexByte(path, '/')
+	if i < 0 || path[i+1:] == "" {
+		return nil, ErrDSN.WithDetail("%s: no project", raw)
+	}
+
+	dsn := &DSN{
+		raw:     raw,
+		key:     u.User.Username(),
+		project: path[i+1:],
+		base:    url.URL{Scheme: u.Scheme, Host: u.Host, Path: path[:i]},
+	}
+	return dsn, nil
+}
+
+// EnvelopeURL - адрес приема конвертов
+func (d *DSN) EnvelopeURL() string {
+	u := d.base
+	u.Path += "/api/" + d.project + "/envelope/"
+	return u.String()
+}
+
+// AuthHeader - значение заголовка X-Sentry-Auth
+func (d *DSN) AuthHeader() string {
+	return fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", userAgent, d.key)
+}
+
+// WriteEnvelope - запись события в формате конверта Sentry, например в локальный файл
+func WriteEnvelope(w io.Writer, dsn *DSN, ev *Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	head := map[string]string{"event_id": ev.EventID, "sent_at": ev.Timestamp}
+	if dsn != nil {
+		head["dsn"] = dsn.raw
+	}
+
+	buf, err := json.Marshal(head)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n{\"type\":\"event\",\"length\":%d}\n%s\n", buf, len(body), body)
+	return err
+}
+
+// Client - отправка событий в Sentry-совместимый сервер
+type Client struct {
+	DSN     *DSN
+	Options Options
+	HTTP    *http.Client // По умолчанию http.DefaultClient
+
+	mu    sync.Mutex
+	until time.Time // До этого времени сервер просил не присылать события (429 с Retry-After)
+}
+
+// NewClient - клиент по DSN
+func NewClient(dsn string, opts Options) (*Client, error) {
+	d, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{DSN: d, Options: opts, HTTP: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Capture - отправка ошибки, возвращает идентификатор события, для nil ничего не отправляет
+func (c *Client) Capture(ctx context.Context, err error) (string, error) {
+	if err == nil {
+		return "", nil
+	}
+
+	ev := NewEvent(err, c.Options)
+	return ev.EventID, c.Send(ctx, ev)
+}
+
+// Send - отправка готового события.
+// После ответа 429 или 503 с Retry-After события до истечения паузы не отправляются,
+// а ошибка ErrSend несет оставшуюся паузу в errx.RetryAfter. Отказ с кодом 4xx, кроме 429, не повторяемый.
+func (c *Client) Send(ctx context.Context, ev *Event) error {
+	if wait := c.wait(); wait > 0 {
+		return ErrSend.WithRetryAfter(wait).WithDetail("rate limited for %s", wait)
+	}
+
+	var buf bytes.Buffer
+
+	if err := WriteEnvelope(&buf, c.DSN, ev); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.DSN.EnvelopeURL(), &buf)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("X-Sentry-Auth", c.DSN.AuthHeader())
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return ErrSend.WithReason(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	if wait := retryAfter(resp.Header.Get("Retry-After"), time.Now()); wait > 0 &&
+		(resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		c.mu.Lock()
+		c.until = time.Now().Add(wait)
+		c.mu.Unlock()
+		return ErrSend.WithRetryAfter(wait).WithDetail("%s", resp.Status)
+	}
+
+	// Кроме 429 ответы 4xx повторная отправка не исправит
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return ErrSend.WithRetryable(false).WithDetail("%s", resp.Status)
+	}
+	return ErrSend.WithDetail("%s", resp.Status)
+}
+
+// wait - сколько еще ждать после Retry-After
+func (c *Client) wait() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Until(c.until)
+}
+
+// retryAfter - пауза из заголовка Retry-After: секунды или HTTP-дата
+func retryAfter(val string, now time.Time) time.Duration {
+	if val == "" {
+		return 0
+	}
+
+	if sec, err := strconv.Atoi(val); err == nil {
+		return time.Duration(sec) * time.Second
+	}
+
+	if t, err := http.ParseTime(val); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// Write - приемник для report.Reporter: отправляет полные отчеты, сводки пропускает
+func (c *Client) Write(e report.Entry) error {
+	if e.Summary() {
+		return nil
+	}
+
+	_, err := c.Capture(context.Background(), e.Err)
+	return err
+}
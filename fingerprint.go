@@ -0,0 +1,119 @@
+package errx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// FingerprintOptions - что учитывается в отпечатке ошибки
+type FingerprintOptions struct {
+	Modules []string // Модули, чьи кадры стека учитываются, по умолчанию главный модуль сборки
+	Lines   bool     // Учитывать номера строк
+	Detail  bool     // Учитывать шаблон детализации, аргументы не учитываются никогда
+}
+
+var fpOptions atomic.Value
+
+func init() {
+	opts := FingerprintOptions{}
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" {
+		opts.Modules = []string{bi.Main.Path}
+	}
+	fpOptions.Store(opts)
+}
+
+// SetFingerprintOptions - настройка вычисления отпечатков для всего процесса
+func SetFingerprintOptions(opts FingerprintOptions) { fpOptions.Store(opts) }
+
+// volatileRx - части текста сторонних ошибок, которые меняются от вызова к вызову:
+// строки в кавычках, пути к файлам, числа, адреса и шестнадцатеричные идентификаторы
+var volatileRx = regexp.MustCompile(`"[^"]*"|'[^']*'|(?:[A-Za-z]:)?(?:[\\/][^\s\\/:"']+)+[\\/]?|\b(?:0x)?[0-9A-Fa-f]*[0-9][0-9A-Fa-f]*\b`)
+
+// Fingerprint - устойчивый отпечаток цепочки для группировки одинаковых ошибок.
+// Учитывает тексты и происхождение слоев и нормализованный стек, но не Debug и не аргументы WithDetail.
+// У сторонних ошибок учитывается тип и текст без чисел, путей, адресов и строк в кавычках.
+// Отпечаток слоя собирается из его данных и отпечатка причины, для распакованных слоев
+// используется отпечаток, вычисленный на отправляющей стороне.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if e, ok := err.(*v1Error); ok {
+		if e.fp != "" {
+			return e.fp
+		}
+		return e.fingerprint(Fingerprint(e.reason))
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%T: %s\n", err, volatileRx.ReplaceAllString(err.Error(), "*"))
+
+	if next := Fingerprint(errors.Unwrap(err)); next != "" {
+		fmt.Fprintf(hash, "%s\n", next)
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+// fingerprint - отпечаток слоя при известном отпечатке причины, так цепочка считается один раз снизу вверх
+func (e *v1Error) fingerprint(next string) string {
+	if e.fp != "" {
+		return e.fp
+	}
+
+	org, _ := e.lineage()
+	return layerFingerprint(org, e.text, e.tpl, e.frames(), next)
+}
+
+// ViewFingerprint - отпечаток представления тем же способом, что и Fingerprint, например для разобранного ParseText.
+// Готовые отпечатки слоев берутся как есть. Происхождение слоев в тексте не передается,
+// поэтому у разобранного текста отпечаток свой, но одинаковые ошибки группируются одинаково.
+func ViewFingerprint(v *View) string {
+	if v == nil {
+		return ""
+	}
+
+	if v.Fingerprint != "" {
+		return v.Fingerprint
+	}
+
+	frames := v.Frames
+	if frames == nil {
+		frames = parseFrames(v.Stack)
+	}
+	return layerFingerprint("", v.Text, "", frames, ViewFingerprint(v.Next))
+}
+
+// layerFingerprint - отпечаток слоя errx по его происхождению, тексту, шаблону детализации, стеку и отпечатку причины
+func layerFingerprint(org, text, tpl string, frames []Frame, next string) string {
+	opts := fpOptions.Load().(FingerprintOptions)
+	hash := sha256.New()
+
+	fmt.Fprintf(hash, "%s %s\n", org, text)
+
+	if opts.Detail {
+		fmt.Fprintf(hash, "%s\n", tpl)
+	}
+
+	for _, f := range frames {
+		if len(opts.Modules) > 0 && !f.InModule(opts.Modules...) {
+			continue
+		}
+
+		if opts.Lines {
+			fmt.Fprintf(hash, "%s:%d\n", f.Func, f.Line)
+		} else {
+			fmt.Fprintf(hash, "%s\n", f.Func)
+		}
+	}
+
+	if next != "" {
+		fmt.Fprintf(hash, "%s\n", next)
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
@@ -0,0 +1,49 @@
+package errx
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync/atomic"
+)
+
+// FormatOptions - настройки вывода %v и %+v
+type FormatOptions struct {
+	// Normalize - стек без номеров строк и без кадров стандартной библиотеки (runtime, testing, reflect),
+	// чтобы %+v можно было сравнивать с эталоном целиком. Предназначено для тестов.
+	Normalize bool
+}
+
+var fmtOptions atomic.Value
+
+func init() { fmtOptions.Store(FormatOptions{}) }
+
+// SetFormatOptions - настройка вывода Format для всего процесса
+func SetFormatOptions(opts FormatOptions) { fmtOptions.Store(opts) }
+
+// Keys - ключи отладки по алфавиту
+func (v *View) Keys() []string { return sortedKeys(v.Debug) }
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stackLines - строки стека для Format с учетом настроек
+func stackLines(list []Frame) []string {
+	if !fmtOptions.Load().(FormatOptions).Normalize {
+		return formatFrames(list)
+	}
+
+	res := make([]string, 0, len(list))
+	for _, f := range list {
+		if !isStd(f) {
+			res = append(res, fmt.Sprintf("%s -> %s()", path.Base(f.File), path.Base(f.Func)))
+		}
+	}
+	return res
+}
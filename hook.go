@@ -0,0 +1,88 @@
+package errx
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// Event - событие жизненного цикла ошибки
+type Event int
+
+// События для подписчиков AddHook
+const (
+	EventCreate  Event = iota + 1 // Ошибка получена из шаблона через WithStack или With*
+	EventWrap                     // WithReason добавил причину
+	EventPack                     // Ошибка упакована через Pack
+	EventUnpack                   // Ошибка распакована через Unpack
+	EventRecover                  // Recover перехватил панику
+)
+
+var eventNames = map[Event]string{
+	EventCreate:  "create",
+	EventWrap:    "wrap",
+	EventPack:    "pack",
+	EventUnpack:  "unpack",
+	EventRecover: "recover",
+}
+
+func (ev Event) String() string { return eventNames[ev] }
+
+// Hook - подписчик на события ошибок
+type Hook func(ev Event, err Error)
+
+var hooks = struct {
+	sync.Mutex
+	list atomic.Value // []*Hook
+}{}
+
+// AddHook - подписка на события всех ошибок, возвращает функцию отписки.
+// Вызовы идут без блокировок, паника в подписчике перехватывается и пишется в лог.
+func AddHook(h Hook) (remove func()) {
+	ptr := &h
+
+	hooks.Lock()
+	defer hooks.Unlock()
+
+	old, _ := hooks.list.Load().([]*Hook)
+	hooks.list.Store(append(append(make([]*Hook, 0, len(old)+1), old...), ptr))
+
+	return func() {
+		hooks.Lock()
+		defer hooks.Unlock()
+
+		old, _ := hooks.list.Load().([]*Hook)
+		list := make([]*Hook, 0, len(old))
+		for i := range old {
+			if old[i] != ptr {
+				list = append(list, old[i])
+			}
+		}
+		hooks.list.Store(list)
+	}
+}
+
+// OnCreate - подписка только на EventCreate, возвращает функцию отписки
+func OnCreate(fn func(Error)) (remove func()) {
+	return AddHook(func(ev Event, err Error) {
+		if ev == EventCreate {
+			fn(err)
+		}
+	})
+}
+
+func notify(ev Event, err Error) {
+	list, _ := hooks.list.Load().([]*Hook)
+	for i := range list {
+		callHook(*list[i], ev, err)
+	}
+}
+
+func callHook(h Hook, ev Event, err Error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("errx: panic in %s hook: %v", ev, rec)
+		}
+	}()
+	h(ev, err)
+}
@@ -1,7 +1,16 @@
 package errx
 
-import "errors"
-
+import (
+	"errors"
+	"time"
+)
+
+// Error - ошибка с прототипом, причиной, стеком и данными для пользователя и отладки.
+//
+// Интерфейс реализуется только этим пакетом, ошибки получаются через New, Define и Unpack.
+// Новые методы добавляются в него без смены мажорной версии, поэтому сторонние реализации
+// перестают собираться, такие изменения перечислены в CHANGELOG.md.
+// Свои типы ошибок встраиваются в цепочку через WithReason, а не реализацией Error.
 type Error interface {
 	/*
 		Error - стандартный интерфейс ошибки.
@@ -40,6 +49,15 @@ type Error interface {
 	*/
 	WithStack() Error
 
+	/*
+		Derive - создание производной ошибки-шаблона с собственным текстом.
+
+		* Исходная ошибка становится прототипом, поэтому errors.Is находит ее у всех наследников
+		* Цепочка прототипов сохраняется при Pack/Unpack
+		* Для восстановления при Unpack результат можно передать в Register
+	*/
+	Derive(text string) Error
+
 	/*
 		WithReason - добавление исходной ошибки для понимания причин возникновения (аналог xerrors.Wrap).
 
@@ -56,6 +74,45 @@ type Error interface {
 	*/
 	WithDetail(tpl string, args ...interface{}) Error
 
+	/*
+		WithMessage - сообщение для пользователя по ключу каталога с именованными параметрами.
+
+		* Текст берется из каталога (SetLocaleOptions) при выводе, а не при создании, и выводится как детализация
+		* Для другой локали представление получается через Localize
+		* Заменяет детализацию WithDetail, ключ учитывается в отпечатке вместо шаблона
+		* Автоматически вызывает WithStack
+	*/
+	WithMessage(key string, params P) Error
+
+	/*
+		WithPublic - явная отметка слоя как публичного или внутреннего.
+
+		* Публичная проекция (Public) показывает первый публичный слой цепочки, внутренние слои скрываются
+		* Без отметки публичны слои с HTTP-статусом в тексте или у прототипов
+		* Отметка переходит к копиям слоя, для шаблонов есть SetPublic
+		* Автоматически вызывает WithStack
+	*/
+	WithPublic(ok bool) Error
+
+	/*
+		WithCorrelationID - идентификатор для поиска ошибки в логах по ответу клиенту.
+
+		* Пустая строка заменяется случайным идентификатором
+		* Выводится в %v вместе с отладкой и попадает в Public
+		* Стек шаблона собирается как в WithStack, а у ошибки со стеком он остается прежним:
+		  идентификатор обычно назначается уже на выходе, далеко от места ошибки
+	*/
+	WithCorrelationID(id string) Error
+
+	/*
+		WithViolations - добавление нарушений проверки полей запроса, обычно к ErrUnprocessable.
+
+		* Нарушения дописываются к уже имеющимся у слоя, накопить их удобнее в ValidationError
+		* Выводятся в %v отдельными строками, попадают в Export, Public и Pack
+		* Автоматически вызывает WithStack
+	*/
+	WithViolations(list ...Violation) Error
+
 	/*
 		WithDebug - добавление объекта отладочных данных.
 
@@ -63,6 +120,22 @@ type Error interface {
 	*/
 	WithDebug(dbg Debug) Error
 
+	/*
+		WithRetryable - явная отметка, можно ли повторять операцию после этой ошибки.
+
+		* Важнее автоматической классификации в IsRetryable
+		* Автоматически вызывает WithStack
+	*/
+	WithRetryable(ok bool) Error
+
+	/*
+		WithRetryAfter - рекомендуемая пауза перед повтором.
+
+		* Ошибка с паузой считается повторяемой, если не было явного WithRetryable(false)
+		* Автоматически вызывает WithStack
+	*/
+	WithRetryAfter(d time.Duration) Error
+
 	/*
 		Export - конвертация в нейтральное от реализации представление
 	*/
@@ -72,6 +145,14 @@ type Error interface {
 		Pack - конвертация в байты для передачи через RPC или другими способами
 	*/
 	Pack() []byte
+
+	/*
+		Public - безопасная для клиента проекция: первый публичный слой, его детализация, код и идентификатор корреляции.
+
+		* Сообщения WithMessage берутся в первой подходящей из указанных локалей
+		* Стек, отладка и внутренние причины не попадают в результат
+	*/
+	Public(locales ...string) *PublicView
 }
 
 type Debug map[string]interface{}
@@ -91,9 +172,17 @@ func Unwrap(err error) error  { return errors.Unwrap(err) }
 
 // View - представление ошибки для простой работы с содержимым
 type View struct {
-	Next   *View
-	Text   string
-	Detail string
-	Stack  []string
-	Debug  map[string]string
+	Next          *View
+	Text          string
+	Detail        string
+	Message       string            // Ключ сообщения из каталога, по которому получена детализация
+	Params        map[string]string // Параметры сообщения
+	Stack         []string
+	Frames        []Frame
+	Debug         map[string]string
+	Fingerprint   string
+	CorrelationID string      // Идентификатор корреляции слоя
+	Violations    []Violation // Нарушения проверки полей запроса
+	StackMode     StackMode   // Способ, которым собран стек
+	Raw           *RawStack   // Адреса стека, если он собран без символизации
 }
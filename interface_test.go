@@ -1,15 +1,30 @@
 package errx_test
 
 import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"os"
+	"path"
 	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/shestakovda/errx"
 	"github.com/stretchr/testify/suite"
 )
 
+//go:embed testdata/locales
+var locales embed.FS
+
 // TestErrors - индивидуальные тесты драйверов
 func TestErrors(t *testing.T) {
 	suite.Run(t, new(InterfaceSuite))
@@ -94,6 +109,336 @@ func (s *InterfaceSuite) TestPack() {
 	s.Contains(fmt.Sprintf("%+v", res), `list: []string{"some", "test"}`)
 }
 
+var (
+	errRegFirst  = errx.Define("registry test")
+	errRegSecond = errx.Register(errx.New("registry test"))
+	errRegChild  = errx.Register(errRegFirst.Derive("registry child"))
+)
+
+func (s *InterfaceSuite) TestRegistry() {
+	s.False(errx.Is(errRegFirst, errRegSecond))
+	s.False(errx.Is(errRegSecond.WithStack(), errRegFirst))
+	s.True(errx.Is(errRegSecond.WithStack(), errRegSecond))
+
+	var dup *errx.Collision
+	for _, c := range errx.Collisions() {
+		if c.Text == "registry test" {
+			d := c
+			dup = &d
+			break
+		}
+	}
+
+	if s.NotNil(dup) {
+		s.Equal("github.com/shestakovda/errx_test", dup.First.Package)
+		s.Equal("github.com/shestakovda/errx_test", dup.Second.Package)
+		s.Equal("interface_test.go", path.Base(dup.First.File))
+		s.NotEqual(dup.First.Line, dup.Second.Line)
+	}
+
+	exp, ok := errx.Lookup("github.com/shestakovda/errx", "404 Not Found")
+	s.True(ok)
+	s.True(exp == errx.ErrNotFound)
+
+	res := errx.Unpack(errx.ErrNotFound.WithDetail("some %d", 42).Pack())
+	s.True(errx.Is(res, errx.ErrNotFound))
+	s.False(errx.Is(res, errx.ErrBadRequest))
+	s.Equal("some 42", res.Export().Detail)
+
+	// Производная ошибка с другим текстом находится по своему тексту и остается наследником шаблона
+	exp, ok = errx.Lookup("github.com/shestakovda/errx_test", "registry child")
+	s.True(ok)
+	s.True(exp == errRegChild)
+
+	res = errx.Unpack(errRegChild.WithDetail("some %d", 7).Pack())
+	s.Equal("registry child", res.Error())
+	s.True(errx.Is(res, errRegChild))
+	s.True(errx.Is(res, errRegFirst))
+	s.False(errx.Is(res, errRegSecond))
+
+	p, _, ok := errx.Sentinel(res)
+	s.True(ok)
+	s.True(p == errRegChild)
+}
+
+var errDerived = errx.Register(errx.ErrNotFound.Derive("user not found"))
+
+func (s *InterfaceSuite) TestLineage() {
+	err := errDerived.WithDetail("id %d", 42)
+	s.True(errx.Is(err, errDerived))
+	s.True(errx.Is(err, errx.ErrNotFound))
+	s.False(errx.Is(err, errx.ErrBadRequest))
+
+	res := errx.Unpack(err.Pack())
+	s.Equal("user not found", res.Error())
+	s.True(errx.Is(res, errDerived))
+	s.True(errx.Is(res, errx.ErrNotFound))
+	s.False(errx.Is(res, errx.ErrBadRequest))
+
+	// Производная ошибка, неизвестная на принимающей стороне
+	remote := errx.ErrNotFound.Derive("remote not found").Derive("remote user not found")
+	res = errx.Unpack(errx.New("outer").WithReason(remote.WithStack()).Pack())
+	s.True(errx.Is(res, errx.ErrNotFound))
+	s.True(errx.Is(res, errx.New("remote not found")))
+	s.False(errx.Is(res, errx.ErrBadRequest))
+	s.Equal("remote user not found", errx.Unwrap(res).Error())
+}
+
+func (s *InterfaceSuite) TestRetryable() {
+	s.False(errx.IsRetryable(nil))
+	s.False(errx.IsRetryable(io.EOF))
+	s.False(errx.IsRetryable(errx.ErrNotFound.WithStack()))
+	s.True(errx.IsRetryable(errx.ErrUnavailable.WithStack()))
+	s.True(errx.IsRetryable(errx.ErrUnavailable.Derive("db unavailable")))
+	s.False(errx.IsRetryable(errx.ErrUnavailable.WithRetryable(false)))
+	s.True(errx.IsRetryable(errx.ErrNotFound.WithRetryable(true)))
+
+	timeout := &net.DNSError{Err: "timeout", IsTimeout: true}
+	reset := &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+
+	s.True(errx.IsRetryable(timeout))
+	s.True(errx.IsRetryable(reset))
+	s.True(errx.IsRetryable(errx.ErrInternal.WithReason(reset)))
+	s.True(errx.IsRetryable(syscall.ETIMEDOUT))
+	s.True(errx.IsRetryable(syscall.ECONNRESET))
+	s.True(errx.IsRetryable(fmt.Errorf("dial: %w", syscall.ECONNABORTED)))
+	s.False(errx.IsRetryable(syscall.ENOENT))
+	s.False(errx.IsRetryable(errx.ErrInternal.WithRetryable(false).WithReason(reset)))
+
+	// Истечение контекста - тоже таймаут, но не повторяется
+	s.False(errx.IsRetryable(context.DeadlineExceeded))
+	s.False(errx.IsRetryable(fmt.Errorf("call: %w", context.Canceled)))
+
+	// Ветки errors.Join проверяются по порядку
+	s.True(errx.IsRetryable(errors.Join(io.EOF, reset)))
+	s.False(errx.IsRetryable(errors.Join(context.Canceled, reset)))
+	s.False(errx.IsRetryable(errors.Join(io.EOF)))
+
+	_, ok := errx.RetryAfter(reset)
+	s.False(ok)
+
+	err := errx.ErrBadRequest.WithRetryAfter(time.Minute)
+	s.True(errx.IsRetryable(err))
+
+	res := errx.Unpack(errx.ErrInternal.WithReason(err).Pack())
+	s.True(errx.IsRetryable(res))
+
+	d, ok := errx.RetryAfter(res)
+	s.True(ok)
+	s.Equal(time.Minute, d)
+
+	s.False(errx.IsRetryable(errx.Unpack(errx.ErrUnavailable.WithRetryable(false).Pack())))
+	s.True(errx.IsRetryable(errx.Unpack(errx.ErrUnavailable.WithStack().Pack())))
+}
+
+func fingerprintA(n int) errx.Error {
+	return errx.ErrNotFound.WithDetail("item %d", n).WithDebug(errx.Debug{"n": n})
+}
+
+func fingerprintB(n int) errx.Error {
+	return errx.ErrNotFound.WithDetail("other %d", n)
+}
+
+func (s *InterfaceSuite) TestFingerprint() {
+	defer errx.SetFingerprintOptions(errx.FingerprintOptions{Modules: []string{"github.com/shestakovda/errx"}})
+
+	s.Empty(errx.Fingerprint(nil))
+	s.Len(errx.Fingerprint(io.EOF), 16)
+	s.Equal(errx.Fingerprint(io.EOF), errx.Fingerprint(io.EOF))
+	s.NotEqual(errx.Fingerprint(io.EOF), errx.Fingerprint(io.ErrUnexpectedEOF))
+
+	// Отпечаток представления: готовый из Export, для разобранного текста - по текстам и стеку
+	plain := errx.New("outer layer").WithReason(errx.New("inner layer"))
+	s.Equal(errx.Fingerprint(plain), errx.ViewFingerprint(plain.Export()))
+	s.Empty(errx.ViewFingerprint(nil))
+
+	v, e := errx.ParseText(fmt.Sprintf("%+v", plain))
+	s.Require().NoError(e)
+	s.Empty(v.Fingerprint)
+	s.Len(errx.ViewFingerprint(v), 16)
+
+	same, e := errx.ParseText(fmt.Sprintf("%+v", errx.New("outer layer").WithReason(errx.New("inner layer"))))
+	s.Require().NoError(e)
+	s.Equal(errx.ViewFingerprint(v), errx.ViewFingerprint(same))
+
+	other, e := errx.ParseText(fmt.Sprintf("%+v", errx.New("outer layer").WithReason(errx.New("other layer"))))
+	s.Require().NoError(e)
+	s.NotEqual(errx.ViewFingerprint(v), errx.ViewFingerprint(other))
+
+	// Кадры модулей в разобранном тексте тоже учитываются, хотя пакет известен только по имени
+	first, e := errx.ParseText("> load\n|       a.go:1 -> errx_test.first()")
+	s.Require().NoError(e)
+	second, e := errx.ParseText("> load\n|       a.go:1 -> errx_test.second()")
+	s.Require().NoError(e)
+	s.NotEqual(errx.ViewFingerprint(first), errx.ViewFingerprint(second))
+
+	// Пути, числа и строки в кавычках в сторонних ошибках не важны, тип и причина - важны
+	s.Equal(errx.Fingerprint(&os.PathError{Op: "open", Path: "/tmp/a1.txt", Err: syscall.ENOENT}),
+		errx.Fingerprint(&os.PathError{Op: "open", Path: "/var/lib/b", Err: syscall.ENOENT}))
+	s.NotEqual(errx.Fingerprint(&os.PathError{Op: "open", Path: "/tmp/a", Err: syscall.ENOENT}),
+		errx.Fingerprint(&os.PathError{Op: "open", Path: "/tmp/a", Err: syscall.EACCES}))
+	s.Equal(errx.Fingerprint(fmt.Errorf("user %d at 0x%x: %q", 42, 0xc000123, "a")),
+		errx.Fingerprint(fmt.Errorf("user %d at 0x%x: %q", 7, 0xc000456, "b")))
+
+	// Аргументы, отладка и номера строк не важны
+	s.Equal(errx.Fingerprint(fingerprintA(1)), errx.Fingerprint(fingerprintA(2)))
+	s.NotEqual(errx.Fingerprint(fingerprintA(1)), errx.Fingerprint(fingerprintB(1)))
+	s.NotEqual(errx.Fingerprint(fingerprintA(1)), errx.Fingerprint(fingerprintA(1).WithReason(io.EOF)))
+
+	// Без фильтра модулей и с номерами строк разные места вызова различаются
+	errx.SetFingerprintOptions(errx.FingerprintOptions{Lines: true})
+	fp := errx.Fingerprint(fingerprintA(1))
+	s.NotEqual(fp, errx.Fingerprint(fingerprintA(1)))
+
+	// Только шаблон детализации, без стека
+	errx.SetFingerprintOptions(errx.FingerprintOptions{Modules: []string{"example.com/none"}, Detail: true})
+	s.Equal(errx.Fingerprint(errx.ErrNotFound.WithDetail("a %d", 1)), errx.Fingerprint(fingerprintA(1).WithDetail("a %d", 2)))
+	s.NotEqual(errx.Fingerprint(fingerprintA(1)), errx.Fingerprint(fingerprintB(1)))
+
+	errx.SetFingerprintOptions(errx.FingerprintOptions{Modules: []string{"github.com/shestakovda/errx"}})
+	err := errx.ErrInternal.WithReason(fingerprintA(1))
+	res := errx.Unpack(err.Pack())
+	s.Equal(errx.Fingerprint(err), errx.Fingerprint(res))
+	s.Equal(errx.Fingerprint(err), res.Export().Fingerprint)
+	s.Equal(errx.Fingerprint(errx.Unwrap(err)), res.Export().Next.Fingerprint)
+}
+
+func (s *InterfaceSuite) TestOnCreate() {
+	var list []errx.Error
+	remove := errx.OnCreate(func(err errx.Error) { list = append(list, err) })
+
+	err := errx.ErrNotFound.WithDetail("some").WithDebug(errx.Debug{"a": 1})
+	_ = errx.New("other").WithStack().WithReason(err)
+	remove()
+	_ = errx.ErrNotFound.WithStack()
+
+	if s.Len(list, 2) {
+		s.Equal("some", list[0].Export().Detail)
+		s.Equal("other", list[1].Error())
+	}
+}
+
+func (s *InterfaceSuite) TestHooks() {
+	var list []string
+	removeBad := errx.AddHook(func(errx.Event, errx.Error) { panic("buggy hook") })
+	remove := errx.AddHook(func(ev errx.Event, err errx.Error) { list = append(list, ev.String()+" "+err.Error()) })
+
+	err := errx.ErrNotFound.WithReason(io.EOF)
+	res := errx.Unpack(err.Pack())
+	_ = res.WithStack()
+	_ = res.WithReason(io.EOF)
+	remove()
+	removeBad()
+	_ = errx.ErrNotFound.WithStack()
+
+	s.Equal([]string{
+		"create 404 Not Found",
+		"wrap 404 Not Found",
+		"pack 404 Not Found",
+		"unpack 404 Not Found",
+		"wrap 404 Not Found",
+	}, list)
+}
+
+func recoverPanic(val interface{}) (err error) {
+	defer errx.Recover(&err)
+	panic(val)
+}
+
+func (s *InterfaceSuite) TestRecover() {
+	var list []errx.Error
+	remove := errx.AddHook(func(ev errx.Event, err errx.Error) {
+		if ev == errx.EventRecover {
+			list = append(list, err)
+		}
+	})
+	defer remove()
+
+	err := recoverPanic("boom")
+	s.True(errx.Is(err, errx.ErrPanic))
+	s.True(errx.Is(err, errx.ErrInternal))
+	s.Equal(500, errx.Status(err))
+	s.Equal("boom", err.(errx.Error).Export().Detail)
+	s.Contains(fmt.Sprintf("%+v", err), "errx_test.recoverPanic()")
+
+	err = recoverPanic(io.EOF)
+	s.True(errx.Is(err, errx.ErrPanic))
+	s.True(errx.Is(err, io.EOF))
+
+	s.NoError(func() (err error) {
+		defer errx.Recover(&err)
+		return nil
+	}())
+
+	s.Len(list, 2)
+}
+
+func (s *InterfaceSuite) TestRedact() {
+	s.Nil(errx.Redact(nil))
+	s.True(errx.Redacted("X-Auth-Token"))
+	s.False(errx.Redacted("user"))
+
+	err := errx.ErrForbidden.WithDebug(errx.Debug{"user": "bob", "Password": "qwerty"}).
+		WithReason(errx.New("inner").WithDebug(errx.Debug{"api_key": "abc"}))
+
+	v := err.Export()
+	r := errx.Redact(v)
+	s.Equal(`"bob"`, r.Debug["user"])
+	s.Equal(errx.RedactedValue, r.Debug["Password"])
+	s.Equal(errx.RedactedValue, r.Next.Debug["api_key"])
+	s.Equal(`"qwerty"`, v.Debug["Password"])
+
+	// Сторонние обертки выводятся своим текстом, цепочка errx под ними - целиком
+	s.Nil(errx.ExportRedacted(nil))
+	e := errx.ExportRedacted(fmt.Errorf("wrap: %w", err))
+	s.Equal("wrap: 403 Forbidden", e.Text)
+	s.Equal(errx.RedactedValue, e.Next.Debug["Password"])
+	s.Equal(errx.RedactedValue, e.Next.Next.Debug["api_key"])
+
+	errx.SetRedactKeys("user")
+	defer errx.SetRedactKeys("password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "apikey")
+	s.Equal(errx.RedactedValue, errx.Redact(v).Debug["user"])
+	s.Equal(`"qwerty"`, errx.Redact(v).Debug["Password"])
+}
+
+func (s *InterfaceSuite) TestInspect() {
+	s.Equal(0, errx.Status(nil))
+	s.Equal(0, errx.Status(io.EOF))
+	s.Equal(0, errx.Status(errx.New("999 Unknown")))
+	s.Equal(404, errx.Status(errDerived.WithStack()))
+	s.Equal(500, errx.Status(errx.ErrInternal.WithReason(errx.ErrNotFound)))
+	s.Equal(404, errx.Status(errx.New("outer").WithReason(errx.ErrNotFound)))
+
+	_, _, ok := errx.Sentinel(errx.New("outer").WithReason(errDerived.WithStack()))
+	s.False(ok)
+
+	_, _, ok = errx.Sentinel(io.EOF)
+	s.False(ok)
+
+	p, org, ok := errx.Sentinel(errDerived.WithStack())
+	s.True(ok)
+	s.True(p == errDerived)
+	s.Equal("github.com/shestakovda/errx_test", org.Package)
+
+	s.Nil(errx.Frames(io.EOF))
+	s.Nil(errx.Frames(errx.ErrNotFound))
+
+	if list := errx.Frames(errx.ErrNotFound.WithStack()); s.NotEmpty(list) {
+		s.Equal("github.com/shestakovda/errx_test.(*InterfaceSuite).TestInspect", list[0].Func)
+		s.Equal("github.com/shestakovda/errx_test", list[0].Package())
+		s.Equal("interface_test.go", path.Base(list[0].File))
+		s.True(list[0].InModule("github.com/shestakovda/errx"))
+		s.False(list[0].InModule("github.com/shestakovda/err", "example.com/app"))
+	}
+
+	// У разобранного текста есть только имя пакета, оно сравнивается с последним элементом пути модуля
+	parsed := errx.Frame{Func: "app.(*Service).Find", File: "service.go"}
+	s.True(parsed.InModule("example.com/app"))
+	s.True(errx.Frame{Func: "app_test.TestFind"}.InModule("example.com/app"))
+	s.False(parsed.InModule("example.com/application"))
+	s.False(errx.Frame{Func: "app.Find", Module: "example.com/other"}.InModule("example.com/app"))
+}
+
 func (s *InterfaceSuite) TestFormat() {
 	const line = ".go:123"
 	const lineS = ".s:1373"
@@ -127,39 +472,32 @@ func (s *InterfaceSuite) TestFormat() {
 	s.Equal(`
 > error 3 (some 42 msg)
 |   err1: &errors.errorString{s:"EOF"}
-|       error_v1.go:123 -> errx.(*v1Error).WithReason()
 |       interface_test.go:123 -> errx_test.(*InterfaceSuite).TestFormat()
 |       value.go:123 -> reflect.Value.call()
 |       value.go:123 -> reflect.Value.Call()
-|       suite.go:123 -> suite.Run.func2()
+|       suite.go:123 -> suite.Run.funcN()
 |       testing.go:123 -> testing.tRunner()
-|       asm_amd64.s:1373 -> runtime.goexit()
 |-> error 2
 |   list: []string{"some", "test"}
-|       error_v1.go:123 -> errx.(*v1Error).WithReason()
-|       interface_test.go:123 -> errx_test.(*InterfaceSuite).TestFormat()
-|       value.go:123 -> reflect.Value.call()
-|       value.go:123 -> reflect.Value.Call()
-|       suite.go:123 -> suite.Run.func2()
-|       testing.go:123 -> testing.tRunner()
-|       asm_amd64.s:1373 -> runtime.goexit()
+|       ... 5 more
 |-> EOF
-`, lineSRx.ReplaceAllString(lineRx.ReplaceAllString(fmt.Sprintf("\n%+v\n", err), line), lineS))
+`, suiteRx.ReplaceAllString(lineSRx.ReplaceAllString(lineRx.ReplaceAllString(fmt.Sprintf("\n%+v\n", err), line), lineS), "suite.Run.funcN("))
 
 	v := err.Export()
 
 	s.Equal("error 3", v.Text)
 	s.Equal("some 42 msg", v.Detail)
-	s.Len(v.Stack, 7)
-	s.Equal(`error_v1.go:123 -> errx.(*v1Error).WithReason()`, lineRx.ReplaceAllString(v.Stack[0], line))
+	s.Len(v.Stack, 5)
+	s.Equal(`interface_test.go:123 -> errx_test.(*InterfaceSuite).TestFormat()`, lineRx.ReplaceAllString(v.Stack[0], line))
 	s.Equal(`&errors.errorString{s:"EOF"}`, v.Debug["err1"])
 
 	if v = v.Next; s.NotNil(v) {
 
 		s.Equal("error 2", v.Text)
 		s.Empty(v.Detail)
-		s.Len(v.Stack, 7)
-		s.Equal(`error_v1.go:123 -> errx.(*v1Error).WithReason()`, lineRx.ReplaceAllString(v.Stack[0], line))
+		// Общие с внешним слоем кадры сворачиваются только при выводе, Export отдает стек целиком
+		s.Len(v.Stack, 5)
+		s.Equal(`interface_test.go:123 -> errx_test.(*InterfaceSuite).TestFormat()`, lineRx.ReplaceAllString(v.Stack[0], line))
 		s.Equal(`[]string{"some", "test"}`, v.Debug["list"])
 
 		if v = v.Next; s.NotNil(v) {
@@ -172,5 +510,647 @@ func (s *InterfaceSuite) TestFormat() {
 	}
 }
 
+func (s *InterfaceSuite) TestFormatShared() {
+	// Одна причина в разных цепочках выводится одновременно, под -race здесь не должно быть гонок
+	reason := errx.ErrNotFound.WithStack()
+	list := []error{errx.New("first").WithReason(reason), errx.New("second").WithStack().WithReason(reason)}
+
+	var wg sync.WaitGroup
+	res := make([]string, 8)
+	views := make([]*errx.View, len(res))
+	packs := make([][]byte, len(res))
+	for i := range res {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := list[i%len(list)].(errx.Error)
+			res[i], views[i], packs[i] = fmt.Sprintf("%+v", err), err.Export(), err.Pack()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range res {
+		err := list[i%len(list)].(errx.Error)
+		s.Equal(fmt.Sprintf("%+v", err), res[i])
+		s.Equal(err.Export(), views[i])
+		s.Equal(err.Pack(), packs[i])
+	}
+
+	// Выгружается не больше 10 причин под внешним слоем
+	deep := errx.New("layer 0")
+	for i := 1; i <= 12; i++ {
+		deep = errx.New(fmt.Sprintf("layer %d", i)).WithReason(deep)
+	}
+
+	n := 0
+	for v := deep.Export(); v != nil; v = v.Next {
+		n++
+	}
+	s.Equal(11, n)
+}
+
+func (s *InterfaceSuite) TestFormatNormalize() {
+	errx.SetFormatOptions(errx.FormatOptions{Normalize: true})
+	defer errx.SetFormatOptions(errx.FormatOptions{})
+
+	err := errx.New("error 1").WithDebug(errx.Debug{
+		"b": 2,
+		"a": 1,
+		"c": 3,
+	}).WithReason(io.EOF)
+
+	s.Equal([]string{"a", "b", "c"}, err.Export().Keys())
+	s.Equal(`
+> error 1
+|   a: 1
+|   b: 2
+|   c: 3
+|       interface_test.go -> errx_test.(*InterfaceSuite).TestFormatNormalize()
+|       suite.go -> suite.Run.funcN()
+|-> EOF
+`, suiteRx.ReplaceAllString(fmt.Sprintf("\n%+v\n", err), "suite.Run.funcN("))
+
+	// Одинаковые ошибки упаковываются одинаково, независимо от порядка обхода карты
+	s.Equal(err.Pack(), err.Pack())
+}
+
+func (s *InterfaceSuite) TestStackTrim() {
+	err := wrapLoad()
+
+	for _, f := range errx.Frames(err) {
+		s.NotEqual("github.com/shestakovda/errx", f.Package())
+		s.NotEqual("runtime", f.Package())
+	}
+
+	// У причины свои только кадры до места вызова, остальное общее с внешним слоем
+	s.Equal(`
+> wrap
+|       interface_test.go -> errx_test.wrapLoad()
+|       interface_test.go -> errx_test.(*InterfaceSuite).TestStackTrim()
+|       suite.go -> suite.Run.funcN()
+|-> load
+|       interface_test.go -> errx_test.load()
+|       interface_test.go -> errx_test.wrapLoad()
+|       ... 5 more
+`, normalized(err))
+
+	errx.SetStackOptions(errx.StackOptions{KeepRuntime: true})
+	defer errx.SetStackOptions(errx.StackOptions{})
+
+	list := errx.Frames(errx.ErrNotFound.WithStack())
+	s.Equal("runtime.goexit", list[len(list)-1].Func)
+}
+
+func (s *InterfaceSuite) TestStackPolicy() {
+	for text, want := range map[string]errx.StackPolicy{
+		"none":           {Mode: errx.StackNone},
+		"caller":         {Mode: errx.StackCaller},
+		"full":           {Mode: errx.StackFull},
+		"full:32":        {Mode: errx.StackFull, Depth: 32},
+		"sampled:0.25":   {Mode: errx.StackSampled, Rate: 0.25},
+		"sampled:0.5:16": {Mode: errx.StackSampled, Rate: 0.5, Depth: 16},
+	} {
+		p, err := errx.ParseStackPolicy(text)
+		s.NoError(err, text)
+		s.Equal(want, p, text)
+	}
+
+	for _, text := range []string{"", "all", "none:1", "caller:3", "full:x", "sampled", "sampled:2", "sampled:0.1:2:3"} {
+		_, err := errx.ParseStackPolicy(text)
+		s.Error(err, text)
+	}
+
+	defer errx.SetStackOptions(errx.StackOptions{})
+
+	errx.SetStackOptions(errx.StackOptions{Policy: errx.StackPolicy{Mode: errx.StackNone}})
+	err := errx.ErrNotFound.WithStack()
+	s.Empty(errx.Frames(err))
+	s.Equal(errx.StackNone, err.Export().StackMode)
+
+	errx.SetStackOptions(errx.StackOptions{Policy: errx.StackPolicy{Mode: errx.StackCaller}})
+	if list := errx.Frames(errx.ErrNotFound.WithStack()); s.Len(list, 1) {
+		s.Equal("github.com/shestakovda/errx_test.(*InterfaceSuite).TestStackPolicy", list[0].Func)
+	}
+
+	errx.SetStackOptions(errx.StackOptions{Policy: errx.StackPolicy{Mode: errx.StackFull, Depth: 2}})
+	s.Len(errx.Frames(errx.ErrNotFound.WithStack()), 2)
+
+	errx.SetStackOptions(errx.StackOptions{Policy: errx.StackPolicy{Mode: errx.StackSampled, Rate: 0}})
+	err = errx.ErrNotFound.WithStack()
+	s.Len(errx.Frames(err), 1)
+	s.Equal(errx.StackCaller, err.Export().StackMode)
+
+	errx.SetStackOptions(errx.StackOptions{Policy: errx.StackPolicy{Mode: errx.StackSampled, Rate: 1}})
+	err = errx.ErrNotFound.WithStack()
+	s.True(len(errx.Frames(err)) > 1)
+	s.Equal(errx.StackSampled, err.Export().StackMode)
+	s.Equal(errx.StackSampled, errx.Unpack(err.Pack()).Export().StackMode)
+
+	// Политика шаблона действует на наследников и важнее политики процесса
+	errx.SetStackOptions(errx.StackOptions{})
+	base := errx.New("stack policy test")
+	errx.SetStackPolicy(base, errx.StackPolicy{Mode: errx.StackNone})
+
+	err = base.Derive("derived").WithDetail("some")
+	s.Empty(errx.Frames(err))
+	s.Equal("none", err.Export().StackMode.String())
+	s.Equal(errx.StackFull, errx.ErrNotFound.WithStack().Export().StackMode)
+
+	// У слоя без стека способ не известен
+	s.Equal(errx.StackUnknown, errx.New("no stack").Export().StackMode)
+	s.Equal("unknown", errx.StackUnknown.String())
+
+	// Без стека ошибка все равно не считается шаблоном
+	created := 0
+	defer errx.OnCreate(func(errx.Error) { created++ })()
+	base.WithStack().WithDetail("more")
+	s.Equal(1, created)
+}
+
+func (s *InterfaceSuite) TestRawStack() {
+	errx.SetStackOptions(errx.StackOptions{Raw: true})
+	defer errx.SetStackOptions(errx.StackOptions{})
+
+	err := errx.ErrNotFound.WithStack()
+	buf := err.Pack()
+
+	// Упаковываются только адреса, кадры процесс той же сборки получает сам
+	m := errx.GetRootAsErrorModel(buf, 0)
+	s.Zero(m.StackLength())
+	s.NotZero(m.PcsLength())
+	s.Equal(errx.BuildID(), string(m.BuildId()))
+
+	if list := errx.Frames(errx.Unpack(buf)); s.NotEmpty(list) {
+		s.Equal("github.com/shestakovda/errx_test.(*InterfaceSuite).TestRawStack", list[0].Func)
+	}
+
+	// Адреса символизируются один раз, дальше отдаются те же кадры
+	first, second := errx.Frames(err), errx.Frames(err)
+	s.Require().NotEmpty(first)
+	s.Same(&first[0], &second[0])
+
+	// Кадры errx и runtime отбрасываются при символизации, глубина стека сохраняется
+	errx.SetStackOptions(errx.StackOptions{Raw: true, Policy: errx.StackPolicy{Mode: errx.StackCaller}})
+	panicked := func() (err error) {
+		defer errx.Recover(&err)
+		var m map[string]int
+		m["a"] = 1
+		return nil
+	}()
+
+	if list := errx.Frames(panicked); s.Len(list, 1) {
+		s.Equal("github.com/shestakovda/errx_test.(*InterfaceSuite).TestRawStack.func1", list[0].Func)
+	}
+
+	s.NotEmpty(errx.BuildID())
+	_, e := errx.ReadBuildID("interface_test.go")
+	s.Error(e)
+}
+
+func (s *InterfaceSuite) TestLinks() {
+	err := errx.ErrNotFound.WithStack()
+	list := errx.Frames(err)
+	s.Require().NotEmpty(list)
+
+	s.Equal("github.com/shestakovda/errx", list[0].Module)
+	s.Equal("interface_test.go", list[0].Path)
+	s.Equal(list[0].Path, errx.Frames(errx.Unpack(err.Pack()))[0].Path)
+	s.Empty(list[0].Link())
+
+	errx.SetLinkOptions(errx.LinkOptions{
+		Template: "https://git.example.com/{module}/blob/{version}/{path}#L{line}",
+		Modules:  []string{"github.com/shestakovda/errx"},
+	})
+	defer errx.SetLinkOptions(errx.LinkOptions{})
+
+	s.Equal(fmt.Sprintf("https://git.example.com/github.com/shestakovda/errx/blob/HEAD/interface_test.go#L%d", list[0].Line), list[0].Link())
+
+	// Для чужих модулей и стандартной библиотеки ссылок нет
+	for _, f := range list[1:] {
+		s.Empty(f.Link(), f.Func)
+	}
+
+	for version, ref := range map[string]string{
+		"v1.2.3":                               "v1.2.3",
+		"v2.0.0+incompatible":                  "v2.0.0",
+		"v0.0.0-20240101120000-abcdef123456":   "abcdef123456",
+		"v1.2.4-0.20240101120000-abcdef123456": "abcdef123456",
+	} {
+		f := errx.Frame{Module: "github.com/shestakovda/errx/sub", Version: version, Path: "sub/a.go", Line: 7}
+		s.Equal("https://git.example.com/github.com/shestakovda/errx/sub/blob/"+ref+"/sub/a.go#L7", f.Link())
+	}
+
+	// Замененный модуль ищется по исходному пути, версия берется у замены
+	mods := errx.NewBuildModules(&debug.BuildInfo{Deps: []*debug.Module{{
+		Path:    "example.com/lib",
+		Version: "v1.0.0",
+		Replace: &debug.Module{Path: "example.com/fork", Version: "v1.0.1"},
+	}}})
+	f := mods.Resolve(errx.Frame{Func: "example.com/lib/sub.Do", File: "/src/sub/do.go", Line: 3})
+	s.Equal("example.com/lib", f.Module)
+	s.Equal("v1.0.1", f.Version)
+	s.Equal("sub/do.go", f.Path)
+
+	data, e := json.Marshal(list[0])
+	s.Require().NoError(e)
+	s.Contains(string(data), `"Path":"interface_test.go"`)
+	s.Contains(string(data), `"Link":"https://git.example.com/`)
+
+	data, e = json.Marshal(errx.Frame{Func: "main.main"})
+	s.Require().NoError(e)
+	s.NotContains(string(data), "Link")
+}
+
+func (s *InterfaceSuite) TestParseText() {
+	err := wrapLoad().WithDetail("some (nested) %s", "detail").WithDebug(errx.Debug{
+		"list": []string{"a", "b"},
+		"user": 42,
+	})
+	err = errx.New("top").WithReason(err)
+
+	for _, verb := range []string{"%s", "%v", "%+v"} {
+		text := fmt.Sprintf(verb, err)
+		v, e := errx.ParseText(text)
+		s.Require().NoError(e, text)
+
+		// Повторный вывод разобранного совпадает с исходным
+		want := err.Export()
+		for got := v; want != nil; got, want = got.Next, want.Next {
+			s.Require().NotNil(got, text)
+			s.Equal(want.Text, got.Text, text)
+			s.Equal(want.Detail, got.Detail, text)
+
+			if verb == "%s" {
+				break
+			}
+
+			s.Equal(len(want.Debug), len(got.Debug), text)
+			for key := range want.Debug {
+				s.Equal(want.Debug[key], got.Debug[key], text)
+			}
+
+			if verb == "%+v" {
+				s.Equal(want.Stack, got.Stack, text)
+			}
+		}
+	}
+
+	// Многострочные значения отладки и ошибки вне errx
+	v, e := errx.ParseText("> some text (detail)\n|   query: SELECT *\nFROM users\n|   user: 1\n|-> EOF\n")
+	s.Require().NoError(e)
+	s.Equal("some text", v.Text)
+	s.Equal("SELECT *\nFROM users", v.Debug["query"])
+	s.Equal("1", v.Debug["user"])
+	s.Equal("EOF", v.Next.Text)
+
+	// Продолжение с "|" восстанавливается, если это не префикс строки слоя
+	v, e = errx.ParseText("> table\n|   rows: | a |\n| b |\n|   user: 1")
+	s.Require().NoError(e)
+	s.Equal("| a |\n| b |", v.Debug["rows"])
+	s.Equal("1", v.Debug["user"])
+
+	for _, text := range []string{"", "text", "> a\n|   no value", "> a\nstray", "> a\n|-> b\n|       ... 3 more", "> a\n|       ... 3 more"} {
+		_, e = errx.ParseText(text)
+		s.True(errx.Is(e, errx.ErrBadRequest), text)
+	}
+}
+
+func (s *InterfaceSuite) TestParsePanic() {
+	data, err := os.ReadFile("testdata/panic.txt")
+	s.Require().NoError(err)
+
+	list, err := errx.ParsePanic(string(data))
+	s.Require().NoError(err)
+	s.Require().Len(list, 2)
+
+	s.Equal("panic: first [recovered]; panic: runtime error: index out of range [5] with length 3", list[0].Text)
+	s.Equal("goroutine 1 [running]", list[0].Detail)
+	s.Equal([]string{
+		"service.go:42 -> main.(*Service).Find()",
+		"panic.go:770 -> panic()",
+		"main.go:8 -> main.main()",
+	}, list[0].Stack)
+	s.Equal("/home/dev/app/service.go", list[0].Frames[0].File)
+
+	s.Equal("goroutine 7 [chan receive, 2 minutes]", list[1].Text)
+	s.Equal([]string{"worker.go:15 -> main.worker()", "main.go:6 -> main.main()"}, list[1].Stack)
+
+	// Стек текущей горутины совпадает с тем, что собирает errx
+	list, err = errx.ParsePanic(string(debug.Stack()))
+	s.Require().NoError(err)
+	s.Require().Len(list, 1)
+
+	frames := errx.Frames(errx.ErrNotFound.WithStack())
+	s.Equal(frames[0].Func, list[0].Frames[1].Func)
+	s.Equal(frames[0].File, list[0].Frames[1].File)
+
+	_, err = errx.ParsePanic("no trace")
+	s.True(errx.Is(err, errx.ErrNotFound))
+}
+
+func (s *InterfaceSuite) TestParsePacked() {
+	src := errx.ErrNotFound.WithDetail("id %d", 42).WithDebug(errx.Debug{"key": "val"})
+
+	err, exc := errx.ParsePacked(src.Pack())
+	s.Require().NoError(exc)
+	s.True(errx.Is(err, errx.ErrNotFound))
+	s.Equal("id 42", err.Export().Detail)
+
+	buf := src.Pack()
+	for _, bad := range [][]byte{nil, {1, 2, 3}, {0xff, 0xff, 0xff, 0x7f, 1, 2, 3, 4, 5}, buf[:len(buf)/2]} {
+		_, exc = errx.ParsePacked(bad)
+		s.True(errx.Is(exc, errx.ErrNotAcceptable), "%x", bad)
+	}
+}
+
+func load() errx.Error { return errx.New("load").WithStack() }
+
+func wrapLoad() errx.Error {
+	err := load()
+	return errx.New("wrap").WithReason(err)
+}
+
+// normalized - вывод %+v без номеров строк и стандартной библиотеки
+func normalized(err error) string {
+	errx.SetFormatOptions(errx.FormatOptions{Normalize: true})
+	defer errx.SetFormatOptions(errx.FormatOptions{})
+	return suiteRx.ReplaceAllString(fmt.Sprintf("\n%+v\n", err), "suite.Run.funcN(")
+}
+
 var lineRx = regexp.MustCompile(`\.go:\d+`)
 var lineSRx = regexp.MustCompile(`\.s:\d+`)
+
+// suiteRx - номер замыкания testify в стеке меняется от версии к версии
+var suiteRx = regexp.MustCompile(`suite\.Run\.func\d+\(`)
+
+func (s *InterfaceSuite) TestMessage() {
+	catalog := errx.NewCatalog()
+	s.Require().NoError(catalog.LoadFS(locales, "testdata/locales"))
+	s.Equal([]string{"en", "pt", "ru"}, catalog.Locales())
+
+	errx.SetLocaleOptions(errx.LocaleOptions{Catalog: catalog})
+	defer errx.SetLocaleOptions(errx.LocaleOptions{})
+
+	err := errx.ErrUnprocessable.WithMessage("order.too_large", errx.P{"max": 10})
+	s.Equal("> 422 Unprocessable Entity (Order can include at most 10 items)", fmt.Sprintf("%s", err))
+	s.Equal("order.too_large", err.Export().Message)
+	s.Equal(map[string]string{"max": "10"}, err.Export().Params)
+
+	// Запрошенная локаль, ее общий вариант и локаль по умолчанию
+	s.Equal("В заказе может быть не больше 10 товаров", errx.Localize(err, "ru-RU").Detail)
+	s.Equal("Order can include at most 10 items", errx.Localize(err, "de", "pt").Detail)
+	s.Equal("Order can include at most 1 item",
+		errx.Localize(errx.ErrUnprocessable.WithMessage("order.too_large", errx.P{"max": 1}), "de").Detail)
+
+	// Причина тоже локализуется, детализация WithDetail остается как есть
+	wrap := errx.ErrBadRequest.WithDetail("checkout").WithReason(err)
+	view := errx.Localize(wrap, "ru")
+	s.Equal("checkout", view.Detail)
+	s.Equal("В заказе может быть не больше 10 товаров", view.Next.Detail)
+
+	for _, c := range []struct {
+		locale string
+		count  interface{}
+		want   string
+	}{
+		{"en", 0, "Cart is empty"},
+		{"en", 1, "1 item in cart"},
+		{"en", "1.0", "1.0 items in cart"},
+		{"ru", 0, "Корзина пуста"},
+		{"ru", 21, "21 товар в корзине"},
+		{"ru", 3, "3 товара в корзине"},
+		{"ru", 11, "11 товаров в корзине"},
+		{"ru", 1.5, "1.5 товара в корзине"},
+		{"pt-BR", 0, "0 item no carrinho"},
+		{"pt-BR", 2, "2 itens no carrinho"},
+	} {
+		err := errx.New("cart").WithMessage("cart.items", errx.P{"count": c.count})
+		s.Equal(c.want, errx.Localize(err, c.locale).Detail, "%s %v", c.locale, c.count)
+	}
+
+	catalog.SetPluralRule("en", func(int64, int) string { return "other" })
+	s.Equal("1 items in cart", errx.Localize(errx.New("cart").WithMessage("cart.items", errx.P{"count": 1})).Detail)
+
+	// Ключ учитывается в отпечатке как шаблон детализации, параметры - нет
+	errx.SetFingerprintOptions(errx.FingerprintOptions{Modules: []string{"github.com/shestakovda/errx"}, Detail: true})
+	s.Equal(errx.Fingerprint(errx.ErrUnprocessable.WithMessage("order.too_large", errx.P{"max": 5})), errx.Fingerprint(err))
+	s.NotEqual(errx.Fingerprint(errx.ErrUnprocessable.WithMessage("order.empty", nil)), errx.Fingerprint(err))
+	errx.SetFingerprintOptions(errx.FingerprintOptions{Modules: []string{"github.com/shestakovda/errx"}})
+
+	// Без сообщения в каталоге выводится ключ, WithDetail заменяет сообщение
+	s.Equal("> 422 Unprocessable Entity (order.missing)", fmt.Sprintf("%s", errx.ErrUnprocessable.WithMessage("order.missing", nil)))
+	s.Equal("> 422 Unprocessable Entity (plain)", fmt.Sprintf("%s", err.WithDetail("plain")))
+	s.Empty(err.WithDetail("plain").Export().Message)
+
+	// Ключ и параметры переживают Pack, а без каталога остается текст отправителя
+	buf := err.Pack()
+	s.Equal("Заказ пуст", errx.Localize(errx.Unpack(errx.ErrUnprocessable.WithMessage("order.empty", nil).Pack()), "ru").Detail)
+	s.Equal("В заказе может быть не больше 10 товаров", errx.Localize(errx.Unpack(buf), "ru").Detail)
+
+	errx.SetLocaleOptions(errx.LocaleOptions{})
+	view = errx.Unpack(buf).Export()
+	s.Equal("Order can include at most 10 items", view.Detail)
+	s.Equal(map[string]string{"max": "10"}, view.Params)
+}
+
+func (s *InterfaceSuite) TestCatalog() {
+	catalog := errx.NewCatalog()
+
+	s.Require().NoError(catalog.LoadTOML("en_US", []byte(`
+[greeting]
+'hello.world' = "Hello, {name}! {unknown}"
+"quoted \"key\"" = 'C:\path' # литерал без экранирования
+`)))
+
+	text, locale, ok := catalog.Message("greeting.hello.world", map[string]string{"name": "Ann"}, "en-us")
+	s.True(ok)
+	s.Equal("en-us", locale)
+	s.Equal("Hello, Ann! {unknown}", text)
+
+	text, _, ok = catalog.Message(`greeting.quoted "key"`, nil, "en-US")
+	s.True(ok)
+	s.Equal(`C:\path`, text)
+
+	_, _, ok = catalog.Message("greeting.hello.world", nil, "en")
+	s.False(ok)
+
+	// Экранирование в строках только то, что есть в TOML
+	s.Require().NoError(catalog.LoadTOML("de", []byte(`escaped = "a\tb \"c\" \\ \u00e9"`)))
+	text, _, _ = catalog.Message("escaped", nil, "de")
+	s.Equal("a\tb \"c\" \\ é", text)
+
+	for _, bad := range []string{
+		"key",
+		"key = 42",
+		"key = \"\"\"multi\"\"\"",
+		"[table",
+		"a = \"x\"\na = \"y\"",
+		"a = \"x\"\n[a]",
+		"bad key = \"x\"",
+		`a = "\x41"`,
+		`a = "\a"`,
+		`a = "\'"`,
+	} {
+		s.True(errx.Is(catalog.LoadTOML("en", []byte(bad)), errx.ErrBadRequest), bad)
+	}
+
+	s.True(errx.Is(catalog.LoadJSON("en", []byte(`{"a": 1}`)), errx.ErrBadRequest))
+	s.True(errx.Is(catalog.LoadJSON("en", []byte(`{"a": {"one": "x"}}`)), errx.ErrBadRequest))
+	s.True(errx.Is(catalog.LoadFS(locales, "missing"), errx.ErrNotFound))
+
+	s.Equal([]string{"pt-br", "pt", "en"}, errx.LocaleChain("pt_BR", "PT", "en"))
+}
+
+var (
+	errOrder  = errx.Define("order rejected")
+	errSecret = errx.Register(errx.ErrForbidden.Derive("token expired"))
+)
+
+func (s *InterfaceSuite) TestPublic() {
+	errx.SetPublic(errOrder, true)
+	errx.SetPublic(errSecret, false)
+
+	cause := errx.New("select failed").WithDebug(errx.Debug{"query": "SELECT 1"})
+
+	for _, c := range []struct {
+		err  error
+		want errx.PublicView
+	}{
+		{io.EOF, errx.PublicView{Code: 500, Message: "500 Internal Server Error"}},
+		{errx.New("load").WithReason(cause), errx.PublicView{Code: 500, Message: "500 Internal Server Error"}},
+		{errx.ErrNotFound.WithDetail("order %d", 7), errx.PublicView{Code: 404, Message: "404 Not Found", Detail: "order 7"}},
+		{errx.New("load").WithReason(errx.ErrNotFound.WithDetail("order %d", 7).WithReason(cause)),
+			errx.PublicView{Code: 404, Message: "404 Not Found", Detail: "order 7"}},
+		{errx.ErrNotFound.WithDetail("order %d", 7).WithPublic(false).WithReason(cause),
+			errx.PublicView{Code: 404, Message: "404 Not Found"}},
+		{errx.New("load").WithReason(errOrder.Derive("order too large").WithDetail("max 10")),
+			errx.PublicView{Code: 500, Message: "order too large", Detail: "max 10"}},
+		{errx.New("custom").WithPublic(true).WithCorrelationID("c1").WithReason(cause),
+			errx.PublicView{Code: 500, Message: "custom", CorrelationID: "c1"}},
+		{errx.New("busy").WithPublic(true).WithReason(errx.ErrUnavailable.WithReason(cause)),
+			errx.PublicView{Code: 500, Message: "busy"}},
+		{errSecret.WithDetail("user %d", 42), errx.PublicView{Code: 403, Message: "403 Forbidden"}},
+	} {
+		s.Equal(&c.want, errx.Public(c.err), "%v", c.err)
+	}
+
+	s.Nil(errx.Public(nil))
+	s.Nil(errx.PublicError(nil))
+
+	// Идентификатор корреляции выводится в %v и переживает Pack и ParseText
+	err := errx.ErrNotFound.WithReason(cause).WithCorrelationID("")
+	id := errx.CorrelationID(err)
+	s.Len(id, 16)
+	s.Equal(id, err.Public().CorrelationID)
+	s.Equal("> 404 Not Found\n|   correlation_id: "+id+"\n|-> select failed\n|   query: \"SELECT 1\"", fmt.Sprintf("%v", err))
+	s.Equal(id, errx.CorrelationID(errx.Unpack(err.Pack())))
+
+	// Стек ошибки остается там, где она возникла
+	placed := errx.ErrNotFound.WithStack()
+	s.Equal(errx.Frames(placed), errx.Frames(func() errx.Error { return placed.WithCorrelationID("c2") }()))
+	s.Equal(errx.Fingerprint(placed), errx.Fingerprint(placed.WithCorrelationID("c2")))
+
+	s.True(errx.ValidCorrelationID("req-1.a_b:c"))
+	for _, bad := range []string{"", "a b", "a\nb", "ид", strings.Repeat("a", 65)} {
+		s.False(errx.ValidCorrelationID(bad), bad)
+	}
+
+	view, perr := errx.ParseText(fmt.Sprintf("%v", err))
+	s.Require().NoError(perr)
+	s.Equal(id, view.CorrelationID)
+	s.Nil(view.Debug)
+
+	// Публичная ошибка - один слой без стека, отладки и причин, с происхождением и отпечатком цепочки
+	pub := errx.Unpack(errx.PublicError(errx.New("load").WithReason(err.WithDetail("order %d", 7))).Pack())
+	s.True(errx.Is(pub, errx.ErrNotFound))
+	s.False(errx.Is(pub, cause))
+	s.Equal("> 404 Not Found (order 7)\n|   correlation_id: "+id, fmt.Sprintf("%+v", pub))
+
+	// Отметка публичности передается через Pack
+	s.Equal("order 7", errx.Public(errx.Unpack(errx.New("load").WithReason(errx.New("hidden").WithPublic(true).WithDetail("order %d", 7)).Pack())).Detail)
+	s.Empty(errx.Public(errx.Unpack(errSecret.WithDetail("user %d", 42).Pack())).Detail)
+}
+
+func (s *InterfaceSuite) TestValidation() {
+	catalog := errx.NewCatalog()
+	s.Require().NoError(catalog.LoadFS(locales, "testdata/locales"))
+
+	errx.SetLocaleOptions(errx.LocaleOptions{Catalog: catalog})
+	defer errx.SetLocaleOptions(errx.LocaleOptions{})
+
+	s.Nil(errx.NewValidationError().Err())
+	s.Equal("/items/2/a~1b~0c", errx.Pointer("items", 2, "a/b~c"))
+
+	// Вложенные проверки добавляются с префиксом пути, сторонние ошибки - нарушением по самому префиксу
+	item := errx.NewValidationError().Add("/qty", "min", "field.min", errx.P{"min": 1})
+	v := errx.NewValidationError().
+		Add("/email", "invalid_format", "invalid format", nil).
+		Merge(errx.Pointer("items", 2), item.Err()).
+		Merge(errx.Pointer("address"), errx.ErrBadRequest.WithDetail("unknown city")).
+		Merge(errx.Pointer("phone"), nil)
+
+	s.Equal(3, v.Len())
+	err := v.Err()
+	s.True(errx.Is(err, errx.ErrUnprocessable))
+	s.Equal(422, errx.Status(err))
+
+	list := errx.Violations(errx.New("create order").WithReason(err))
+	s.Equal([]errx.Violation{
+		{Path: "/email", Code: "invalid_format", Message: "invalid format"},
+		{Path: "/items/2/qty", Code: "min", Message: "field.min", Params: map[string]string{"min": "1"}},
+		{Path: "/address", Code: "invalid", Detail: "unknown city"},
+	}, list)
+	s.Equal("items[2].qty: must be at least 1", list[1].String())
+	s.Equal("должно быть не меньше 1", list[1].Text("ru"))
+	s.Nil(errx.Violations(errx.ErrUnprocessable))
+
+	// Текст внутренней ошибки в нарушение не попадает, путь без "/" в начале дополняется
+	inner := errx.NewValidationError().Merge("city", errx.New("lookup 10.0.0.1: timeout")).Add("zip", "required", "required", nil).Violations()
+	s.Equal([]errx.Violation{
+		{Path: "/city", Code: "invalid", Message: "invalid"},
+		{Path: "/zip", Code: "required", Message: "required"},
+	}, inner)
+	s.Equal("zip: required", inner[1].String())
+
+	keyed := errx.NewValidationError().Merge("/qty", errx.ErrBadRequest.WithMessage("field.min", errx.P{"min": 1})).Violations()
+	s.Equal("должно быть не меньше 1", keyed[0].Text("ru"))
+
+	// Детализация публичного слоя - готовый текст, ключом каталога и шаблоном она не считается
+	literal := errx.NewValidationError().
+		Merge("/qty", errx.ErrBadRequest.WithDetail("field.min")).
+		Merge("/size", errx.ErrBadRequest.WithDetail("{min}")).
+		Violations()
+	s.Equal("field.min", literal[0].Text("ru"))
+	s.Equal("{min}", literal[1].Text())
+	s.Equal("field.min", errx.Public(errx.ErrUnprocessable.WithViolations(literal...), "ru").Violations[0].Message)
+	s.Equal("name: required", errx.Violation{Path: "name", Message: "required"}.String())
+
+	// Вывод %v и обратный разбор
+	text := "> 422 Unprocessable Entity" +
+		"\n|   ! /email: invalid format [invalid_format]" +
+		"\n|   ! /items/2/qty: must be at least 1 [min]" +
+		"\n|   ! /address: unknown city [invalid]"
+	s.Equal(text, fmt.Sprintf("%v", err))
+	s.Equal("> 422 Unprocessable Entity\n|   ! : empty body", fmt.Sprintf("%v", errx.ErrUnprocessable.WithViolations(errx.Violation{Message: "empty body"})))
+
+	view, perr := errx.ParseText(text)
+	s.Require().NoError(perr)
+	s.Equal(errx.Violation{Path: "/items/2/qty", Code: "min", Message: "must be at least 1"}, view.Violations[1])
+	s.Nil(view.Debug)
+
+	// Нарушения переживают Pack, в публичной проекции сообщения уже в запрошенной локали
+	s.Equal(list, err.Export().Violations)
+	s.Equal(list, errx.Violations(errx.Unpack(err.Pack())))
+	s.Equal("> 422 Unprocessable Entity\n|   ! /email: invalid format [invalid_format]\n|   ! /items/2/qty: must be at least 1 [min]\n|   ! /address: unknown city [invalid]",
+		fmt.Sprintf("%v", errx.Unpack(err.Pack())))
+
+	pub := errx.Public(errx.New("create order").WithReason(err.WithDebug(errx.Debug{"user": 42})), "ru")
+	s.Equal(422, pub.Code)
+	s.Equal("должно быть не меньше 1", pub.Violations[1].Message)
+	s.Equal(map[string]string{"min": "1"}, pub.Violations[1].Params)
+	s.Equal("должно быть не меньше 1", errx.Violations(errx.Unpack(errx.PublicError(err, "ru").Pack()))[1].Message)
+
+	// Нарушения дописываются к уже имеющимся
+	s.Len(errx.Violations(err.WithViolations(errx.Violation{Path: "/name", Code: "required", Message: "required"})), 4)
+	s.Len(errx.Violations(err), 3)
+}
@@ -0,0 +1,183 @@
+package errx
+
+import (
+	"encoding/json"
+	"path"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// LinkOptions - ссылки из кадров стека на исходники в системе контроля версий
+type LinkOptions struct {
+	// Template - шаблон ссылки с подстановками {module}, {version}, {path} и {line},
+	// например https://git.example.com/{module}/blob/{version}/{path}#L{line}
+	Template string
+	Modules  []string // Модули, для кадров которых строятся ссылки, по умолчанию главный модуль сборки
+}
+
+var linkOptions atomic.Value
+
+// BuildModules - модули сборки для определения модуля, версии и относительного пути кадров
+type BuildModules struct {
+	main string   // Путь пакета main
+	list []Module // От длинных путей к коротким, чтобы вложенные модули находились раньше
+}
+
+// Module - модуль сборки и его версия
+type Module struct {
+	Path    string
+	Version string
+}
+
+var selfModules *BuildModules
+
+func init() {
+	opts := LinkOptions{}
+	bi, ok := debug.ReadBuildInfo()
+
+	if ok && bi.Main.Path != "" {
+		opts.Modules = []string{bi.Main.Path}
+	}
+
+	linkOptions.Store(opts)
+	selfModules = NewBuildModules(bi)
+}
+
+// SetLinkOptions - настройка ссылок на исходники для всего процесса
+func SetLinkOptions(opts LinkOptions) { linkOptions.Store(opts) }
+
+// NewBuildModules - индекс модулей по информации о сборке, nil дает пустой индекс.
+// Для главного модуля без версии берется ревизия VCS, если она записана при сборке.
+func NewBuildModules(bi *debug.BuildInfo) *BuildModules {
+	m := new(BuildModules)
+
+	if bi == nil {
+		return m
+	}
+
+	m.main = bi.Path
+
+	if bi.Main.Path != "" {
+		main := Module{Path: bi.Main.Path, Version: bi.Main.Version}
+
+		if main.Version == "" || main.Version == "(devel)" {
+			for _, s := range bi.Settings {
+				if s.Key == "vcs.revision" {
+					main.Version = s.Value
+				}
+			}
+		}
+
+		m.list = append(m.list, main)
+	}
+
+	// Пакеты в стеке называются по исходному пути модуля, от замены берется только версия
+	for _, dep := range bi.Deps {
+		mod := Module{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			mod.Version = dep.Replace.Version
+		}
+		m.list = append(m.list, mod)
+	}
+
+	sort.SliceStable(m.list, func(i, j int) bool { return len(m.list[i].Path) > len(m.list[j].Path) })
+	return m
+}
+
+// Resolve - кадр с модулем, версией и путем файла относительно корня модуля
+func (m *BuildModules) Resolve(f Frame) Frame {
+	pkg := strings.TrimSuffix(f.Package(), "_test")
+
+	if pkg == "main" && m.main != "" {
+		pkg = m.main
+	}
+
+	for _, mod := range m.list {
+		if pkg != mod.Path && !strings.HasPrefix(pkg, mod.Path+"/") {
+			continue
+		}
+
+		f.Module, f.Version = mod.Path, mod.Version
+		f.Path = path.Base(f.File)
+
+		if dir := strings.TrimPrefix(pkg[len(mod.Path):], "/"); dir != "" {
+			f.Path = dir + "/" + f.Path
+		}
+		break
+	}
+	return f
+}
+
+// Link - ссылка на строку исходника по шаблону из SetLinkOptions, пустая строка если ее не построить
+func (f Frame) Link() string {
+	opts := linkOptions.Load().(LinkOptions)
+
+	if opts.Template == "" || f.Module == "" || f.Path == "" {
+		return ""
+	}
+
+	if len(opts.Modules) > 0 && !inModule(f.Module, opts.Modules) {
+		return ""
+	}
+
+	return strings.NewReplacer(
+		"{module}", f.Module,
+		"{version}", vcsRef(f.Version),
+		"{path}", f.Path,
+		"{line}", strconv.Itoa(f.Line),
+	).Replace(opts.Template)
+}
+
+// MarshalJSON - кадр вместе со ссылкой на исходник, если она есть
+func (f Frame) MarshalJSON() ([]byte, error) {
+	type frame Frame
+
+	return json.Marshal(struct {
+		frame
+		Link string `json:",omitempty"`
+	}{frame(f), f.Link()})
+}
+
+// source - модуль, версия и путь для упаковки в виде "module@version/path"
+func (f Frame) source() string {
+	if f.Module == "" {
+		return ""
+	}
+	return f.Module + "@" + f.Version + "/" + f.Path
+}
+
+// parseSource - обратное преобразование source, пути модулей не содержат "@", а версии - "/"
+func (f Frame) parseSource(s string) Frame {
+	i := strings.IndexByte(s, '@')
+	if i < 0 {
+		return f
+	}
+
+	j := strings.IndexByte(s[i:], '/')
+	if j < 0 {
+		return f
+	}
+
+	f.Module, f.Version, f.Path = s[:i], s[i+1:i+j], s[i+j+1:]
+	return f
+}
+
+// vcsRef - ревизия для ссылки: для псевдоверсий это хэш коммита, иначе сама версия
+func vcsRef(version string) string {
+	version = strings.TrimSuffix(version, "+incompatible")
+
+	if version == "" || version == "(devel)" {
+		return "HEAD"
+	}
+
+	// Псевдоверсия вида v0.0.0-20240101120000-abcdef123456
+	if parts := strings.Split(version, "-"); len(parts) >= 3 && len(parts[len(parts)-1]) == 12 {
+		if ts := parts[len(parts)-2]; len(ts) >= 14 && strings.Trim(ts[len(ts)-14:], "0123456789") == "" {
+			return parts[len(parts)-1]
+		}
+	}
+	return version
+}
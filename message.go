@@ -0,0 +1,88 @@
+package errx
+
+import "sync/atomic"
+
+// P - именованные параметры сообщения из каталога
+type P map[string]interface{}
+
+// LocaleOptions - каталог сообщений для пользователя и локали по умолчанию
+type LocaleOptions struct {
+	Catalog  *Catalog
+	Fallback []string // Локали после запрошенных, в них же выводит Format, по умолчанию "en"
+}
+
+var localeOptions atomic.Value
+
+func init() { SetLocaleOptions(LocaleOptions{}) }
+
+// SetLocaleOptions - настройка сообщений для всего процесса
+func SetLocaleOptions(opts LocaleOptions) {
+	if opts.Catalog == nil {
+		opts.Catalog = NewCatalog()
+	}
+
+	if opts.Fallback == nil {
+		opts.Fallback = []string{"en"}
+	}
+
+	localeOptions.Store(opts)
+}
+
+// Localize - представление цепочки с сообщениями WithMessage в первой подходящей из запрошенных локалей
+func Localize(err error, locales ...string) *View {
+	if err == nil {
+		return nil
+	}
+	return export(err).Localize(locales...)
+}
+
+// Localize - копия представления всей цепочки, в которой детализация слоев с ключом сообщения
+// заново получена из каталога для запрошенных локалей, а затем локалей по умолчанию.
+// Если сообщения нет ни в одной, остается прежняя детализация.
+func (v *View) Localize(locales ...string) *View {
+	if v == nil {
+		return nil
+	}
+
+	res := *v
+	res.Next = v.Next.Localize(locales...)
+
+	if v.Message != "" {
+		if text, ok := localize(v.Message, v.Params, locales); ok {
+			res.Detail = text
+		}
+	}
+	return &res
+}
+
+func (e *v1Error) WithMessage(key string, params P) Error {
+	err := e.withStack()
+	err.tpl, err.detail, err.msg, err.params = key, "", key, stringParams(params)
+	return e.created(err)
+}
+
+// userDetail - детализация для вывода в локали по умолчанию
+func (e *v1Error) userDetail() string { return e.detailIn(nil) }
+
+// detailIn - сообщение в первой подходящей локали, если оно есть в каталоге.
+// Иначе то, что посчитала отправляющая сторона, а если ее нет, то сам ключ.
+func (e *v1Error) detailIn(locales []string) string {
+	if e.msg == "" {
+		return e.detail
+	}
+
+	if text, ok := localize(e.msg, e.params, locales); ok {
+		return text
+	}
+
+	if e.detail != "" {
+		return e.detail
+	}
+	return e.msg
+}
+
+func localize(key string, params map[string]string, locales []string) (string, bool) {
+	opts := localeOptions.Load().(LocaleOptions)
+	text, _, ok := opts.Catalog.Message(key, params, append(append([]string(nil), locales...), opts.Fallback...)...)
+	return text, ok
+}
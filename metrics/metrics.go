@@ -0,0 +1,252 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/shestakovda/errx"
+)
+
+// Метки, по которым можно считать ошибки
+const (
+	LabelSentinel = "sentinel" // Ближайшая зарегистрированная ошибка: "пакет:текст"
+	LabelText     = "text"     // Текст ошибки
+	LabelStatus   = "status"   // HTTP-статус по errx.Status
+	LabelFrame    = "frame"    // Верхний кадр стека из модулей приложения
+)
+
+// Overflow - значение всех меток для ошибок сверх лимита серий
+const Overflow = "_other"
+
+// Options - настройки счетчиков
+type Options struct {
+	Labels  []string // Разрешенные метки, по умолчанию sentinel и status
+	Limit   int      // Максимум серий, по умолчанию 1000
+	Modules []string // Модули приложения для метки frame, по умолчанию главный модуль сборки
+	Expvar  string   // Имя переменной expvar, по умолчанию "errx", "-" отключает публикацию
+}
+
+// Collector - счетчики созданных ошибок с метками
+type Collector struct {
+	mu     sync.Mutex
+	opts   Options
+	size   int
+	series sync.Map // string -> *series
+	remove func()
+}
+
+type series struct {
+	labels []string
+	count  int64
+}
+
+var published sync.Map
+
+var errxPackage = reflect.TypeOf(errx.View{}).PkgPath()
+
+// Enable - подписка счетчиков на создание ошибок через errx.OnCreate, отключается через Close.
+// Счетчики публикуются в expvar, одно имя можно занять только один раз за процесс.
+func Enable(opts Options) (*Collector, error) {
+	c := New(opts)
+
+	for _, name := range c.opts.Labels {
+		switch name {
+		case LabelSentinel, LabelText, LabelStatus, LabelFrame:
+		default:
+			return nil, errx.ErrBadRequest.WithDetail("unknown label %q", name)
+		}
+	}
+
+	if c.opts.Expvar != "-" {
+		if _, ok := published.LoadOrStore(c.opts.Expvar, true); ok || expvar.Get(c.opts.Expvar) != nil {
+			return nil, errx.ErrBadRequest.WithDetail("expvar %q already published", c.opts.Expvar)
+		}
+		expvar.Publish(c.opts.Expvar, c)
+	}
+
+	c.remove = errx.OnCreate(c.Observe)
+	return c, nil
+}
+
+// New - создание счетчиков без подписки, ошибки передаются в Observe вручную
+func New(opts Options) *Collector {
+	if len(opts.Labels) == 0 {
+		opts.Labels = []string{LabelSentinel, LabelStatus}
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = 1000
+	}
+
+	if opts.Modules == nil {
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" {
+			opts.Modules = []string{bi.Main.Path}
+		}
+	}
+
+	if opts.Expvar == "" {
+		opts.Expvar = "errx"
+	}
+
+	return &Collector{opts: opts}
+}
+
+// Close - отписка от создания ошибок
+func (c *Collector) Close() {
+	if c.remove != nil {
+		c.remove()
+	}
+}
+
+// Observe - учет ошибки
+func (c *Collector) Observe(err errx.Error) {
+	labels := make([]string, len(c.opts.Labels))
+	for i := range c.opts.Labels {
+		labels[i] = c.label(c.opts.Labels[i], err)
+	}
+
+	key := strings.Join(labels, "\x00")
+	item, ok := c.series.Load(key)
+
+	if !ok {
+		item = c.add(key, labels)
+	}
+
+	atomic.AddInt64(&item.(*series).count, 1)
+}
+
+// add - новая серия, сверх лимита ошибки попадают в общую серию Overflow
+func (c *Collector) add(key string, labels []string) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, ok := c.series.Load(key); ok {
+		return item
+	}
+
+	if c.size >= c.opts.Limit {
+		for i := range labels {
+			labels[i] = Overflow
+		}
+
+		key = strings.Join(labels, "\x00")
+		if item, ok := c.series.Load(key); ok {
+			return item
+		}
+	}
+
+	item := &series{labels: labels}
+	c.series.Store(key, item)
+	c.size++
+	return item
+}
+
+func (c *Collector) label(name string, err errx.Error) string {
+	switch name {
+	case LabelSentinel:
+		if p, org, ok := errx.Sentinel(err); ok {
+			return org.Package + ":" + p.Error()
+		}
+	case LabelText:
+		return err.Error()
+	case LabelStatus:
+		if code := errx.Status(err); code != 0 {
+			return strconv.Itoa(code)
+		}
+	case LabelFrame:
+		for _, f := range errx.Frames(err) {
+			if f.Package() != errxPackage && f.InModule(c.opts.Modules...) {
+				return path.Base(f.Func)
+			}
+		}
+	}
+	return ""
+}
+
+// String - счетчики в JSON для expvar
+func (c *Collector) String() string {
+	var buf strings.Builder
+
+	buf.WriteByte('[')
+	for i, s := range c.snapshot() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j := range c.opts.Labels {
+			buf.Write(jsonString(c.opts.Labels[j]))
+			buf.WriteByte(':')
+			buf.Write(jsonString(s.labels[j]))
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "\"count\":%d}", s.count)
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+// ServeHTTP - счетчики в текстовом формате Prometheus
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo - запись счетчиков в текстовом формате Prometheus
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var buf strings.Builder
+
+	buf.WriteString("# HELP errx_errors_total Errors created by errx.\n")
+	buf.WriteString("# TYPE errx_errors_total counter\n")
+
+	for _, s := range c.snapshot() {
+		buf.WriteString("errx_errors_total{")
+		for j := range c.opts.Labels {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(c.opts.Labels[j])
+			buf.WriteString(`="`)
+			buf.WriteString(escape(s.labels[j]))
+			buf.WriteByte('"')
+		}
+		fmt.Fprintf(&buf, "} %d\n", s.count)
+	}
+
+	n, err := io.WriteString(w, buf.String())
+	return int64(n), err
+}
+
+func (c *Collector) snapshot() []series {
+	list := make([]series, 0, c.opts.Limit/8)
+
+	c.series.Range(func(_, item interface{}) bool {
+		s := item.(*series)
+		list = append(list, series{labels: s.labels, count: atomic.LoadInt64(&s.count)})
+		return true
+	})
+
+	sort.Slice(list, func(i, j int) bool {
+		return strings.Join(list[i].labels, "\x00") < strings.Join(list[j].labels, "\x00")
+	})
+	return list
+}
+
+func jsonString(s string) []byte {
+	buf, _ := json.Marshal(s)
+	return buf
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string { return escaper.Replace(s) }
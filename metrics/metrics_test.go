@@ -0,0 +1,107 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/metrics"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMetrics(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
+
+type MetricsSuite struct {
+	suite.Suite
+}
+
+// expvarSeq - expvar общий на процесс, поэтому при -count у каждого запуска свое имя
+var expvarSeq int
+
+func expvarName(prefix string) string {
+	expvarSeq++
+	return fmt.Sprintf("%s_%d", prefix, expvarSeq)
+}
+
+func handle() error { return errx.ErrNotFound.WithDetail("id %d", 1).WithDebug(errx.Debug{"id": 1}) }
+
+func (s *MetricsSuite) TestEnable() {
+	name := expvarName("errx_metrics_test")
+	c, err := metrics.Enable(metrics.Options{
+		Labels: []string{metrics.LabelSentinel, metrics.LabelStatus, metrics.LabelFrame},
+		Expvar: name,
+	})
+	s.Require().NoError(err)
+
+	for i := 0; i < 3; i++ {
+		_ = handle()
+	}
+	_ = errx.New("custom \"quoted\"\nerror").WithStack()
+
+	c.Close()
+	_ = handle()
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	s.Equal("text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	s.Equal(`# HELP errx_errors_total Errors created by errx.
+# TYPE errx_errors_total counter
+errx_errors_total{sentinel="",status="",frame="metrics_test.(*MetricsSuite).TestEnable"} 1
+errx_errors_total{sentinel="github.com/shestakovda/errx:404 Not Found",status="404",frame="metrics_test.handle"} 3
+`, rec.Body.String())
+
+	s.Equal(`[{"sentinel":"","status":"","frame":"metrics_test.(*MetricsSuite).TestEnable","count":1},`+
+		`{"sentinel":"github.com/shestakovda/errx:404 Not Found","status":"404","frame":"metrics_test.handle","count":3}]`,
+		expvar.Get(name).String())
+}
+
+func (s *MetricsSuite) TestOptions() {
+	_, err := metrics.Enable(metrics.Options{Labels: []string{"sentinal"}, Expvar: "-"})
+	s.True(errx.Is(err, errx.ErrBadRequest))
+
+	name := expvarName("errx_metrics_twice")
+	c, err := metrics.Enable(metrics.Options{Expvar: name})
+	s.Require().NoError(err)
+	defer c.Close()
+
+	_, err = metrics.Enable(metrics.Options{Expvar: name})
+	s.True(errx.Is(err, errx.ErrBadRequest))
+
+	_, err = metrics.Enable(metrics.Options{Expvar: "memstats"})
+	s.True(errx.Is(err, errx.ErrBadRequest))
+}
+
+func (s *MetricsSuite) TestJSON() {
+	c := metrics.New(metrics.Options{Labels: []string{metrics.LabelText}})
+	c.Observe(errx.New("тест\x01 \u2028 <b>").WithStack())
+
+	var list []map[string]interface{}
+	s.Require().NoError(json.Unmarshal([]byte(c.String()), &list))
+	s.Equal([]map[string]interface{}{{"text": "тест\x01 \u2028 <b>", "count": float64(1)}}, list)
+}
+
+func (s *MetricsSuite) TestLimit() {
+	c := metrics.New(metrics.Options{Labels: []string{metrics.LabelText}, Limit: 2})
+
+	c.Observe(errx.New("a\\b").WithStack())
+	c.Observe(errx.New("c\"d").WithStack())
+	c.Observe(errx.New("c\"d").WithStack())
+	c.Observe(errx.New("e").WithStack())
+	c.Observe(errx.New("f").WithStack())
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	s.Equal(`# HELP errx_errors_total Errors created by errx.
+# TYPE errx_errors_total counter
+errx_errors_total{text="_other"} 2
+errx_errors_total{text="a\\b"} 1
+errx_errors_total{text="c\"d"} 2
+`, rec.Body.String())
+}
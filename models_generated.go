@@ -86,12 +86,266 @@ func KeyValueEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
 
-type ErrorModelT struct {
-	Next   *ErrorModelT
+type ProtoModelT struct {
 	Text   string
-	Detail string
-	Stack  []string
-	Debug  []*KeyValueT
+	Origin string
+}
+
+func (t *ProtoModelT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	if t == nil {
+		return 0
+	}
+	textOffset := builder.CreateString(t.Text)
+	originOffset := builder.CreateString(t.Origin)
+	ProtoModelStart(builder)
+	ProtoModelAddText(builder, textOffset)
+	ProtoModelAddOrigin(builder, originOffset)
+	return ProtoModelEnd(builder)
+}
+
+func (rcv *ProtoModel) UnPackTo(t *ProtoModelT) {
+	t.Text = string(rcv.Text())
+	t.Origin = string(rcv.Origin())
+}
+
+func (rcv *ProtoModel) UnPack() *ProtoModelT {
+	if rcv == nil {
+		return nil
+	}
+	t := &ProtoModelT{}
+	rcv.UnPackTo(t)
+	return t
+}
+
+type ProtoModel struct {
+	_tab flatbuffers.Table
+}
+
+func GetRootAsProtoModel(buf []byte, offset flatbuffers.UOffsetT) *ProtoModel {
+	n := flatbuffers.GetUOffsetT(buf[offset:])
+	x := &ProtoModel{}
+	x.Init(buf, n+offset)
+	return x
+}
+
+func (rcv *ProtoModel) Init(buf []byte, i flatbuffers.UOffsetT) {
+	rcv._tab.Bytes = buf
+	rcv._tab.Pos = i
+}
+
+func (rcv *ProtoModel) Table() flatbuffers.Table {
+	return rcv._tab
+}
+
+func (rcv *ProtoModel) Text() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ProtoModel) Origin() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(6))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func ProtoModelStart(builder *flatbuffers.Builder) {
+	builder.StartObject(2)
+}
+func ProtoModelAddText(builder *flatbuffers.Builder, text flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(text), 0)
+}
+func ProtoModelAddOrigin(builder *flatbuffers.Builder, origin flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(1, flatbuffers.UOffsetT(origin), 0)
+}
+func ProtoModelEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	return builder.EndObject()
+}
+
+type ViolationModelT struct {
+	Path    string
+	Code    string
+	Message string
+	Params  []*KeyValueT
+	Detail  string
+}
+
+func (t *ViolationModelT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	if t == nil {
+		return 0
+	}
+	pathOffset := builder.CreateString(t.Path)
+	codeOffset := builder.CreateString(t.Code)
+	messageOffset := builder.CreateString(t.Message)
+	paramsOffset := flatbuffers.UOffsetT(0)
+	if t.Params != nil {
+		paramsLength := len(t.Params)
+		paramsOffsets := make([]flatbuffers.UOffsetT, paramsLength)
+		for j := 0; j < paramsLength; j++ {
+			paramsOffsets[j] = t.Params[j].Pack(builder)
+		}
+		ViolationModelStartParamsVector(builder, paramsLength)
+		for j := paramsLength - 1; j >= 0; j-- {
+			builder.PrependUOffsetT(paramsOffsets[j])
+		}
+		paramsOffset = builder.EndVector(paramsLength)
+	}
+	detailOffset := builder.CreateString(t.Detail)
+	ViolationModelStart(builder)
+	ViolationModelAddPath(builder, pathOffset)
+	ViolationModelAddCode(builder, codeOffset)
+	ViolationModelAddMessage(builder, messageOffset)
+	ViolationModelAddParams(builder, paramsOffset)
+	ViolationModelAddDetail(builder, detailOffset)
+	return ViolationModelEnd(builder)
+}
+
+func (rcv *ViolationModel) UnPackTo(t *ViolationModelT) {
+	t.Path = string(rcv.Path())
+	t.Code = string(rcv.Code())
+	t.Message = string(rcv.Message())
+	paramsLength := rcv.ParamsLength()
+	t.Params = make([]*KeyValueT, paramsLength)
+	for j := 0; j < paramsLength; j++ {
+		x := KeyValue{}
+		rcv.Params(&x, j)
+		t.Params[j] = x.UnPack()
+	}
+	t.Detail = string(rcv.Detail())
+}
+
+func (rcv *ViolationModel) UnPack() *ViolationModelT {
+	if rcv == nil {
+		return nil
+	}
+	t := &ViolationModelT{}
+	rcv.UnPackTo(t)
+	return t
+}
+
+type ViolationModel struct {
+	_tab flatbuffers.Table
+}
+
+func GetRootAsViolationModel(buf []byte, offset flatbuffers.UOffsetT) *ViolationModel {
+	n := flatbuffers.GetUOffsetT(buf[offset:])
+	x := &ViolationModel{}
+	x.Init(buf, n+offset)
+	return x
+}
+
+func (rcv *ViolationModel) Init(buf []byte, i flatbuffers.UOffsetT) {
+	rcv._tab.Bytes = buf
+	rcv._tab.Pos = i
+}
+
+func (rcv *ViolationModel) Table() flatbuffers.Table {
+	return rcv._tab
+}
+
+func (rcv *ViolationModel) Path() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ViolationModel) Code() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(6))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ViolationModel) Message() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(8))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ViolationModel) Params(obj *KeyValue, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *ViolationModel) ParamsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(10))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *ViolationModel) Detail() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(12))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func ViolationModelStart(builder *flatbuffers.Builder) {
+	builder.StartObject(5)
+}
+func ViolationModelAddPath(builder *flatbuffers.Builder, path flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(path), 0)
+}
+func ViolationModelAddCode(builder *flatbuffers.Builder, code flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(1, flatbuffers.UOffsetT(code), 0)
+}
+func ViolationModelAddMessage(builder *flatbuffers.Builder, message flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(2, flatbuffers.UOffsetT(message), 0)
+}
+func ViolationModelAddParams(builder *flatbuffers.Builder, params flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(3, flatbuffers.UOffsetT(params), 0)
+}
+func ViolationModelAddDetail(builder *flatbuffers.Builder, detail flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(4, flatbuffers.UOffsetT(detail), 0)
+}
+func ViolationModelStartParamsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func ViolationModelEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	return builder.EndObject()
+}
+
+type ErrorModelT struct {
+	Next          *ErrorModelT
+	Text          string
+	Detail        string
+	Stack         []string
+	Debug         []*KeyValueT
+	Origin        string
+	Protos        []*ProtoModelT
+	Retry         byte
+	RetryAfter    int64
+	Fingerprint   string
+	StackMode     byte
+	Pcs           []uint64
+	BuildId       string
+	Module        string
+	PcBase        int64
+	FramePaths    []string
+	Message       string
+	Params        []*KeyValueT
+	Public        byte
+	CorrelationId string
+	Violations    []*ViolationModelT
 }
 
 func (t *ErrorModelT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
@@ -127,12 +381,95 @@ func (t *ErrorModelT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 		}
 		debugOffset = builder.EndVector(debugLength)
 	}
+	originOffset := builder.CreateString(t.Origin)
+	protosOffset := flatbuffers.UOffsetT(0)
+	if t.Protos != nil {
+		protosLength := len(t.Protos)
+		protosOffsets := make([]flatbuffers.UOffsetT, protosLength)
+		for j := 0; j < protosLength; j++ {
+			protosOffsets[j] = t.Protos[j].Pack(builder)
+		}
+		ErrorModelStartProtosVector(builder, protosLength)
+		for j := protosLength - 1; j >= 0; j-- {
+			builder.PrependUOffsetT(protosOffsets[j])
+		}
+		protosOffset = builder.EndVector(protosLength)
+	}
+	fingerprintOffset := builder.CreateString(t.Fingerprint)
+	pcsOffset := flatbuffers.UOffsetT(0)
+	if t.Pcs != nil {
+		pcsLength := len(t.Pcs)
+		ErrorModelStartPcsVector(builder, pcsLength)
+		for j := pcsLength - 1; j >= 0; j-- {
+			builder.PrependUint64(t.Pcs[j])
+		}
+		pcsOffset = builder.EndVector(pcsLength)
+	}
+	buildIdOffset := builder.CreateString(t.BuildId)
+	moduleOffset := builder.CreateString(t.Module)
+	framePathsOffset := flatbuffers.UOffsetT(0)
+	if t.FramePaths != nil {
+		framePathsLength := len(t.FramePaths)
+		framePathsOffsets := make([]flatbuffers.UOffsetT, framePathsLength)
+		for j := 0; j < framePathsLength; j++ {
+			framePathsOffsets[j] = builder.CreateString(t.FramePaths[j])
+		}
+		ErrorModelStartFramePathsVector(builder, framePathsLength)
+		for j := framePathsLength - 1; j >= 0; j-- {
+			builder.PrependUOffsetT(framePathsOffsets[j])
+		}
+		framePathsOffset = builder.EndVector(framePathsLength)
+	}
+	messageOffset := builder.CreateString(t.Message)
+	paramsOffset := flatbuffers.UOffsetT(0)
+	if t.Params != nil {
+		paramsLength := len(t.Params)
+		paramsOffsets := make([]flatbuffers.UOffsetT, paramsLength)
+		for j := 0; j < paramsLength; j++ {
+			paramsOffsets[j] = t.Params[j].Pack(builder)
+		}
+		ErrorModelStartParamsVector(builder, paramsLength)
+		for j := paramsLength - 1; j >= 0; j-- {
+			builder.PrependUOffsetT(paramsOffsets[j])
+		}
+		paramsOffset = builder.EndVector(paramsLength)
+	}
+	correlationIdOffset := builder.CreateString(t.CorrelationId)
+	violationsOffset := flatbuffers.UOffsetT(0)
+	if t.Violations != nil {
+		violationsLength := len(t.Violations)
+		violationsOffsets := make([]flatbuffers.UOffsetT, violationsLength)
+		for j := 0; j < violationsLength; j++ {
+			violationsOffsets[j] = t.Violations[j].Pack(builder)
+		}
+		ErrorModelStartViolationsVector(builder, violationsLength)
+		for j := violationsLength - 1; j >= 0; j-- {
+			builder.PrependUOffsetT(violationsOffsets[j])
+		}
+		violationsOffset = builder.EndVector(violationsLength)
+	}
 	ErrorModelStart(builder)
 	ErrorModelAddNext(builder, nextOffset)
 	ErrorModelAddText(builder, textOffset)
 	ErrorModelAddDetail(builder, detailOffset)
 	ErrorModelAddStack(builder, stackOffset)
 	ErrorModelAddDebug(builder, debugOffset)
+	ErrorModelAddOrigin(builder, originOffset)
+	ErrorModelAddProtos(builder, protosOffset)
+	ErrorModelAddRetry(builder, t.Retry)
+	ErrorModelAddRetryAfter(builder, t.RetryAfter)
+	ErrorModelAddFingerprint(builder, fingerprintOffset)
+	ErrorModelAddStackMode(builder, t.StackMode)
+	ErrorModelAddPcs(builder, pcsOffset)
+	ErrorModelAddBuildId(builder, buildIdOffset)
+	ErrorModelAddModule(builder, moduleOffset)
+	ErrorModelAddPcBase(builder, t.PcBase)
+	ErrorModelAddFramePaths(builder, framePathsOffset)
+	ErrorModelAddMessage(builder, messageOffset)
+	ErrorModelAddParams(builder, paramsOffset)
+	ErrorModelAddPublic(builder, t.Public)
+	ErrorModelAddCorrelationId(builder, correlationIdOffset)
+	ErrorModelAddViolations(builder, violationsOffset)
 	return ErrorModelEnd(builder)
 }
 
@@ -152,6 +489,48 @@ func (rcv *ErrorModel) UnPackTo(t *ErrorModelT) {
 		rcv.Debug(&x, j)
 		t.Debug[j] = x.UnPack()
 	}
+	t.Origin = string(rcv.Origin())
+	protosLength := rcv.ProtosLength()
+	t.Protos = make([]*ProtoModelT, protosLength)
+	for j := 0; j < protosLength; j++ {
+		x := ProtoModel{}
+		rcv.Protos(&x, j)
+		t.Protos[j] = x.UnPack()
+	}
+	t.Retry = rcv.Retry()
+	t.RetryAfter = rcv.RetryAfter()
+	t.Fingerprint = string(rcv.Fingerprint())
+	t.StackMode = rcv.StackMode()
+	pcsLength := rcv.PcsLength()
+	t.Pcs = make([]uint64, pcsLength)
+	for j := 0; j < pcsLength; j++ {
+		t.Pcs[j] = rcv.Pcs(j)
+	}
+	t.BuildId = string(rcv.BuildId())
+	t.Module = string(rcv.Module())
+	t.PcBase = rcv.PcBase()
+	framePathsLength := rcv.FramePathsLength()
+	t.FramePaths = make([]string, framePathsLength)
+	for j := 0; j < framePathsLength; j++ {
+		t.FramePaths[j] = string(rcv.FramePaths(j))
+	}
+	t.Message = string(rcv.Message())
+	paramsLength := rcv.ParamsLength()
+	t.Params = make([]*KeyValueT, paramsLength)
+	for j := 0; j < paramsLength; j++ {
+		x := KeyValue{}
+		rcv.Params(&x, j)
+		t.Params[j] = x.UnPack()
+	}
+	t.Public = rcv.Public()
+	t.CorrelationId = string(rcv.CorrelationId())
+	violationsLength := rcv.ViolationsLength()
+	t.Violations = make([]*ViolationModelT, violationsLength)
+	for j := 0; j < violationsLength; j++ {
+		x := ViolationModel{}
+		rcv.Violations(&x, j)
+		t.Violations[j] = x.UnPack()
+	}
 }
 
 func (rcv *ErrorModel) UnPack() *ErrorModelT {
@@ -249,8 +628,219 @@ func (rcv *ErrorModel) DebugLength() int {
 	return 0
 }
 
+func (rcv *ErrorModel) Origin() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ErrorModel) Protos(obj *ProtoModel, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *ErrorModel) ProtosLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) Retry() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) MutateRetry(n byte) bool {
+	return rcv._tab.MutateByteSlot(18, n)
+}
+
+func (rcv *ErrorModel) RetryAfter() int64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(20))
+	if o != 0 {
+		return rcv._tab.GetInt64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) MutateRetryAfter(n int64) bool {
+	return rcv._tab.MutateInt64Slot(20, n)
+}
+
+func (rcv *ErrorModel) Fingerprint() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(22))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ErrorModel) StackMode() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(24))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) MutateStackMode(n byte) bool {
+	return rcv._tab.MutateByteSlot(24, n)
+}
+
+func (rcv *ErrorModel) Pcs(j int) uint64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(26))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.GetUint64(a + flatbuffers.UOffsetT(j*8))
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) PcsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(26))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) MutatePcs(j int, n uint64) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(26))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.MutateUint64(a+flatbuffers.UOffsetT(j*8), n)
+	}
+	return false
+}
+
+func (rcv *ErrorModel) BuildId() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(28))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ErrorModel) Module() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(30))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ErrorModel) PcBase() int64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(32))
+	if o != 0 {
+		return rcv._tab.GetInt64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) MutatePcBase(n int64) bool {
+	return rcv._tab.MutateInt64Slot(32, n)
+}
+
+func (rcv *ErrorModel) FramePaths(j int) []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(34))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.ByteVector(a + flatbuffers.UOffsetT(j*4))
+	}
+	return nil
+}
+
+func (rcv *ErrorModel) FramePathsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(34))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) Message() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(36))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ErrorModel) Params(obj *KeyValue, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(38))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *ErrorModel) ParamsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(38))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) Public() byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(40))
+	if o != 0 {
+		return rcv._tab.GetByte(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *ErrorModel) MutatePublic(n byte) bool {
+	return rcv._tab.MutateByteSlot(40, n)
+}
+
+func (rcv *ErrorModel) CorrelationId() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(42))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ErrorModel) Violations(obj *ViolationModel, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(44))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *ErrorModel) ViolationsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(44))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
 func ErrorModelStart(builder *flatbuffers.Builder) {
-	builder.StartObject(5)
+	builder.StartObject(21)
 }
 func ErrorModelAddNext(builder *flatbuffers.Builder, next flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(next), 0)
@@ -273,6 +863,69 @@ func ErrorModelAddDebug(builder *flatbuffers.Builder, debug flatbuffers.UOffsetT
 func ErrorModelStartDebugVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
 	return builder.StartVector(4, numElems, 4)
 }
+func ErrorModelAddOrigin(builder *flatbuffers.Builder, origin flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(5, flatbuffers.UOffsetT(origin), 0)
+}
+func ErrorModelAddProtos(builder *flatbuffers.Builder, protos flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(6, flatbuffers.UOffsetT(protos), 0)
+}
+func ErrorModelStartProtosVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func ErrorModelAddRetry(builder *flatbuffers.Builder, retry byte) {
+	builder.PrependByteSlot(7, retry, 0)
+}
+func ErrorModelAddRetryAfter(builder *flatbuffers.Builder, retryAfter int64) {
+	builder.PrependInt64Slot(8, retryAfter, 0)
+}
+func ErrorModelAddFingerprint(builder *flatbuffers.Builder, fingerprint flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(9, flatbuffers.UOffsetT(fingerprint), 0)
+}
+func ErrorModelAddStackMode(builder *flatbuffers.Builder, stackMode byte) {
+	builder.PrependByteSlot(10, stackMode, 0)
+}
+func ErrorModelAddPcs(builder *flatbuffers.Builder, pcs flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(11, flatbuffers.UOffsetT(pcs), 0)
+}
+func ErrorModelStartPcsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(8, numElems, 8)
+}
+func ErrorModelAddBuildId(builder *flatbuffers.Builder, buildId flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(12, flatbuffers.UOffsetT(buildId), 0)
+}
+func ErrorModelAddModule(builder *flatbuffers.Builder, module flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(13, flatbuffers.UOffsetT(module), 0)
+}
+func ErrorModelAddPcBase(builder *flatbuffers.Builder, pcBase int64) {
+	builder.PrependInt64Slot(14, pcBase, 0)
+}
+func ErrorModelAddFramePaths(builder *flatbuffers.Builder, framePaths flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(15, flatbuffers.UOffsetT(framePaths), 0)
+}
+func ErrorModelStartFramePathsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func ErrorModelAddMessage(builder *flatbuffers.Builder, message flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(16, flatbuffers.UOffsetT(message), 0)
+}
+func ErrorModelAddParams(builder *flatbuffers.Builder, params flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(17, flatbuffers.UOffsetT(params), 0)
+}
+func ErrorModelStartParamsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func ErrorModelAddPublic(builder *flatbuffers.Builder, public byte) {
+	builder.PrependByteSlot(18, public, 0)
+}
+func ErrorModelAddCorrelationId(builder *flatbuffers.Builder, correlationId flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(19, flatbuffers.UOffsetT(correlationId), 0)
+}
+func ErrorModelAddViolations(builder *flatbuffers.Builder, violations flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(20, flatbuffers.UOffsetT(violations), 0)
+}
+func ErrorModelStartViolationsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
 func ErrorModelEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
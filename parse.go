@@ -0,0 +1,282 @@
+package errx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	moreRx      = regexp.MustCompile(`^\.\.\. (\d+) more$`)
+	goroutineRx = regexp.MustCompile(`^goroutine (\d+) \[([^\]]*)\]:$`)
+	fileLineRx  = regexp.MustCompile(`^\t(.*):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// ParseText - обратное преобразование вывода Format (%s, %v и %+v) в представление.
+// Кадры, свернутые в "... N more", восстанавливаются из внешнего слоя.
+// Многострочные значения отладки собираются обратно по строкам без префикса "|".
+// Format не экранирует значения, поэтому строка продолжения, которая сама начинается с "|-> " или "|   ",
+// разбирается как строка слоя: такие значения обратно не восстанавливаются, для них есть Pack и Export.
+func ParseText(text string) (*View, error) {
+	lines := strings.Split(strings.TrimRight(strings.TrimLeft(text, "\n"), "\n"), "\n")
+
+	if !strings.HasPrefix(lines[0], "> ") {
+		return nil, ErrBadRequest.WithDetail("line 1: expected \"> text\"")
+	}
+
+	top := new(View)
+	cur := top
+	cur.Text, cur.Detail = splitDetail(lines[0][2:])
+
+	var key string // Ключ последнего значения отладки для продолжения на следующих строках
+
+	for n, line := range lines[1:] {
+		switch {
+		case strings.HasPrefix(line, "|-> "):
+			next := new(View)
+			next.Text, next.Detail = splitDetail(line[4:])
+			cur.Next, cur, key = next, next, ""
+
+		case strings.HasPrefix(line, "|       "):
+			frame := line[8:]
+
+			if m := moreRx.FindStringSubmatch(frame); m != nil {
+				if err := cur.restoreShared(top, m[1]); err != nil {
+					return nil, ErrBadRequest.WithReason(err).WithDetail("line %d", n+2)
+				}
+				break
+			}
+
+			cur.Stack = append(cur.Stack, frame)
+			key = ""
+
+		case strings.HasPrefix(line, "|   "):
+			i := strings.Index(line, ": ")
+			if i < 0 {
+				return nil, ErrBadRequest.WithDetail("line %d: expected \"key: value\"", n+2)
+			}
+
+			if line[4:i] == CorrelationKey {
+				cur.CorrelationID, key = line[i+2:], ""
+				break
+			}
+
+			if strings.HasPrefix(line[4:], violationMark) {
+				cur.Violations, key = append(cur.Violations, parseViolation(line[4+len(violationMark):i], line[i+2:])), ""
+				break
+			}
+
+			if cur.Debug == nil {
+				cur.Debug = make(map[string]string)
+			}
+
+			key = line[4:i]
+			cur.Debug[key] = line[i+2:]
+
+		case key != "":
+			cur.Debug[key] += "\n" + line
+
+		default:
+			return nil, ErrBadRequest.WithDetail("line %d: unexpected %q", n+2, line)
+		}
+	}
+
+	for v := top; v != nil; v = v.Next {
+		if v.Stack != nil {
+			v.Frames = parseFrames(v.Stack)
+		}
+	}
+
+	return top, nil
+}
+
+// restoreShared - последние n кадров внешнего слоя, которые Format свернул
+func (v *View) restoreShared(top *View, count string) error {
+	n, _ := strconv.Atoi(count)
+
+	if v == top {
+		return ErrNotFound.WithDetail("%d shared frames without outer layer", n)
+	}
+
+	outer := top
+	for outer.Next != v {
+		outer = outer.Next
+	}
+
+	if n > len(outer.Stack) {
+		return ErrNotFound.WithDetail("%d shared frames, outer layer has %d", n, len(outer.Stack))
+	}
+
+	v.Stack = append(v.Stack, outer.Stack[len(outer.Stack)-n:]...)
+	return nil
+}
+
+// parseViolation - нарушение из строки "|   ! path: message [code]", код в квадратных скобках необязателен
+func parseViolation(path, text string) Violation {
+	v := Violation{Path: path, Message: text}
+
+	if i := strings.LastIndex(text, " ["); i >= 0 && strings.HasSuffix(text, "]") {
+		v.Message, v.Code = text[:i], text[i+2:len(text)-1]
+	}
+	return v
+}
+
+// splitDetail - текст и детализация из "text (detail)", скобки внутри детализации учитываются
+func splitDetail(s string) (text, detail string) {
+	if !strings.HasSuffix(s, ")") {
+		return s, ""
+	}
+
+	depth := 0
+	for i := len(s) - 1; i > 0; i-- {
+		switch s[i] {
+		case ')':
+			depth++
+		case '(':
+			if depth--; depth == 0 {
+				if s[i-1] != ' ' {
+					return s, ""
+				}
+				return s[:i-1], s[i+1 : len(s)-1]
+			}
+		}
+	}
+	return s, ""
+}
+
+// ParsePanic - горутины из вывода паники или debug.Stack, по представлению на каждую.
+// У горутины, в которой случилась паника, текст - сообщение паники, а состояние горутины - в детализации.
+// Последний кадр "created by" добавляется в стек как обычный.
+func ParsePanic(text string) ([]*View, error) {
+	var list []*View
+	var cur *View
+	var msg []string
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := goroutineRx.FindStringSubmatch(line); m != nil {
+			cur = &View{Text: "goroutine " + m[1] + " [" + m[2] + "]"}
+
+			// Паника относится к первой горутине дампа
+			if list == nil && len(msg) > 0 {
+				cur.Detail, cur.Text = cur.Text, strings.Join(msg, "; ")
+			}
+
+			list = append(list, cur)
+			continue
+		}
+
+		if cur == nil {
+			if s := strings.TrimSpace(line); strings.HasPrefix(s, "panic: ") || strings.HasPrefix(s, "fatal error: ") {
+				msg = append(msg, s)
+			}
+			continue
+		}
+
+		if line == "" {
+			cur = nil
+			continue
+		}
+
+		// Кадр - строка функции и строка файла с отступом табуляцией
+		if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "\t") {
+			continue
+		}
+
+		m := fileLineRx.FindStringSubmatch(lines[i+1])
+		if m == nil {
+			return nil, ErrBadRequest.WithDetail("line %d: expected file and line", i+2)
+		}
+
+		n, _ := strconv.Atoi(m[2])
+		f := Frame{Func: panicFunc(line), File: m[1], Line: n}
+
+		cur.Frames = append(cur.Frames, f)
+		cur.Stack = append(cur.Stack, f.String())
+		i++
+	}
+
+	if list == nil {
+		return nil, ErrNotFound.WithDetail("no goroutines in panic trace")
+	}
+	return list, nil
+}
+
+// panicFunc - имя функции из строки трассировки: без аргументов и без "created by ... in goroutine N"
+func panicFunc(line string) string {
+	if strings.HasPrefix(line, "created by ") {
+		line = strings.TrimPrefix(line, "created by ")
+		if i := strings.Index(line, " in goroutine "); i >= 0 {
+			line = line[:i]
+		}
+		return line
+	}
+
+	if strings.HasSuffix(line, ")") {
+		if i := strings.LastIndexByte(line, '('); i > 0 {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// maxPackedDepth - сколько слоев может быть в упакованной ошибке из недоверенного источника
+const maxPackedDepth = 1000
+
+// ParsePacked - Unpack для данных из недоверенного источника: вместо паники на испорченном буфере
+// возвращает ErrNotAcceptable
+func ParsePacked(buf []byte) (Error, error) {
+	m, err := ReadModel(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(v1Error).importModel(m)
+	notify(EventUnpack, res)
+	return res, nil
+}
+
+// ReadModel - модель упакованной ошибки с проверкой буфера.
+// Глубина цепочки и длины списков ограничены, чтобы ссылки по кругу и огромные длины
+// не исчерпали стек и память, остальные ошибки разбора перехватываются.
+func ReadModel(buf []byte) (m *ErrorModelT, err error) {
+	if len(buf) < 8 {
+		return nil, ErrNotAcceptable.WithDetail("packed error: %d bytes", len(buf))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			m, err = nil, ErrNotAcceptable.WithDetail("packed error: %v", r)
+		}
+	}()
+
+	limit := len(buf) / 4
+	depth := 0
+
+	for cur := GetRootAsErrorModel(buf, 0); cur != nil; cur = cur.Next(nil) {
+		if depth++; depth > maxPackedDepth {
+			return nil, ErrNotAcceptable.WithDetail("packed error: more than %d layers", maxPackedDepth)
+		}
+
+		for _, n := range []int{
+			cur.StackLength(), cur.DebugLength(), cur.ProtosLength(), cur.PcsLength(),
+			cur.FramePathsLength(), cur.ParamsLength(), cur.ViolationsLength(),
+		} {
+			if n > limit {
+				return nil, ErrNotAcceptable.WithDetail("packed error: list of %d items in %d bytes", n, len(buf))
+			}
+		}
+
+		var v ViolationModel
+		for i := 0; i < cur.ViolationsLength(); i++ {
+			if cur.Violations(&v, i) && v.ParamsLength() > limit {
+				return nil, ErrNotAcceptable.WithDetail("packed error: list of %d items in %d bytes", v.ParamsLength(), len(buf))
+			}
+		}
+	}
+
+	return GetRootAsErrorModel(buf, 0).UnPack(), nil
+}
@@ -0,0 +1,122 @@
+package errx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PluralRule - категория множественного числа (zero, one, two, few, many, other)
+// по целой части числа i и количеству цифр дробной части v, как в правилах CLDR
+type PluralRule func(i int64, v int) string
+
+// pluralRules - встроенные правила по языкам, для остальных действует pluralOneOther
+var pluralRules = map[string]PluralRule{
+	"fr": pluralFrench,
+	"pt": pluralFrench,
+	"ru": pluralEastSlavic,
+	"uk": pluralEastSlavic,
+	"be": pluralEastSlavic,
+	"pl": pluralPolish,
+	"cs": pluralCzech,
+	"sk": pluralCzech,
+	"ar": pluralArabic,
+	"ja": pluralNone,
+	"ko": pluralNone,
+	"zh": pluralNone,
+	"vi": pluralNone,
+	"th": pluralNone,
+	"id": pluralNone,
+	"ms": pluralNone,
+}
+
+// pluralOneOther - английский и большинство европейских языков: 1 item, 2 items, 1.0 items
+func pluralOneOther(i int64, v int) string {
+	if i == 1 && v == 0 {
+		return "one"
+	}
+	return "other"
+}
+
+func pluralFrench(i int64, v int) string {
+	if i == 0 || i == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// pluralEastSlavic - 1 товар, 2 товара, 5 товаров, 1.5 товара
+func pluralEastSlavic(i int64, v int) string {
+	switch {
+	case v != 0:
+		return "other"
+	case i%10 == 1 && i%100 != 11:
+		return "one"
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+func pluralPolish(i int64, v int) string {
+	switch {
+	case v != 0:
+		return "other"
+	case i == 1:
+		return "one"
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return "few"
+	}
+	return "many"
+}
+
+func pluralCzech(i int64, v int) string {
+	switch {
+	case v != 0:
+		return "many"
+	case i == 1:
+		return "one"
+	case i >= 2 && i <= 4:
+		return "few"
+	}
+	return "other"
+}
+
+func pluralArabic(i int64, v int) string {
+	switch {
+	case v != 0:
+		return "other"
+	case i == 0:
+		return "zero"
+	case i == 1:
+		return "one"
+	case i == 2:
+		return "two"
+	case i%100 >= 3 && i%100 <= 10:
+		return "few"
+	case i%100 >= 11:
+		return "many"
+	}
+	return "other"
+}
+
+func pluralNone(int64, int) string { return "other" }
+
+// pluralOperands - целая часть и число цифр дробной части из записи числа, "1.50" дает 1 и 2
+func pluralOperands(num string) (i int64, v int, ok bool) {
+	num = strings.TrimPrefix(strings.TrimSpace(num), "-")
+
+	whole, frac := num, ""
+	if dot := strings.IndexByte(num, '.'); dot >= 0 {
+		whole, frac = num[:dot], num[dot+1:]
+	}
+
+	if frac != "" && strings.Trim(frac, "0123456789") != "" {
+		return 0, 0, false
+	}
+
+	i, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return i, len(frac), true
+}
@@ -0,0 +1,205 @@
+package errx
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// CorrelationKey - ключ идентификатора корреляции в выводе %v
+const CorrelationKey = "correlation_id"
+
+const (
+	publicUnknown byte = iota
+	publicYes
+	publicNo
+)
+
+// PublicView - часть ошибки, которую можно отдать клиенту: без стека, отладки и внутренних причин
+type PublicView struct {
+	Code          int         // HTTP-статус цепочки, 500 если его нет
+	Message       string      // Текст первого публичного слоя
+	Detail        string      // Детализация этого слоя, сообщения WithMessage в запрошенной локали
+	CorrelationID string      // Идентификатор для поиска ошибки в логах
+	Violations    []Violation // Нарушения проверки полей этого слоя, сообщения в той же локали
+}
+
+var publicMarks = struct {
+	sync.Mutex
+	byErr atomic.Value // map[*v1Error]bool, копируется при каждом изменении
+}{}
+
+func init() { publicMarks.byErr.Store(map[*v1Error]bool{}) }
+
+// SetPublic - отметка ошибки-шаблона и всех ее наследников через Derive как публичной или внутренней.
+// Без отметок публичны слои, у которых в тексте или в прототипах есть HTTP-статус ("404 Not Found").
+func SetPublic(err Error, ok bool) {
+	e, isV1 := err.(*v1Error)
+	if !isV1 {
+		return
+	}
+
+	publicMarks.Lock()
+	defer publicMarks.Unlock()
+
+	old := publicMarks.byErr.Load().(map[*v1Error]bool)
+	list := make(map[*v1Error]bool, len(old)+1)
+	for k, v := range old {
+		list[k] = v
+	}
+	list[e] = ok
+	publicMarks.byErr.Store(list)
+}
+
+// Public - публичная проекция цепочки, nil для nil.
+// HTTP-статус берется только из публичного слоя и его шаблонов, без него - 500.
+func Public(err error, locales ...string) *PublicView {
+	if err == nil {
+		return nil
+	}
+
+	e := PublicError(err, locales...).(*v1Error)
+	v := &PublicView{Code: Status(e), Message: e.text, Detail: e.detail, CorrelationID: e.id, Violations: e.violations}
+
+	if v.Code == 0 {
+		v.Code = 500
+	}
+	return v
+}
+
+// PublicError - ошибка из одного первого публичного слоя цепочки для передачи наружу через Pack.
+// Сохраняет происхождение слоя, поэтому Is с его шаблонами работает и у получателя,
+// нарушения проверки полей этого слоя с сообщениями в той же локали,
+// а также отпечаток, идентификатор корреляции и признаки повтора всей цепочки.
+// Если публичных слоев нет, вместо них шаблон по HTTP-статусу цепочки или ErrInternal.
+func PublicError(err error, locales ...string) Error {
+	if err == nil {
+		return nil
+	}
+
+	res := &v1Error{
+		fp:     Fingerprint(err),
+		id:     CorrelationID(err),
+		mode:   StackNone,
+		public: publicYes,
+		retry:  retryNo,
+	}
+
+	if IsRetryable(err) {
+		res.retry = retryYes
+	}
+	res.after, _ = RetryAfter(err)
+
+	for cur := err; cur != nil; cur = errors.Unwrap(cur) {
+		if e, ok := cur.(*v1Error); ok && e.isPublic() {
+			res.text, res.proto = e.text, e.sentinel()
+			res.detail, res.msg, res.params = e.detailIn(locales), e.msg, e.params
+			res.violations = localized(e.violations, locales)
+			return res
+		}
+	}
+
+	p := statusError(Status(err))
+	res.text, res.proto = p.text, p
+	return res
+}
+
+func (e *v1Error) Public(locales ...string) *PublicView { return Public(e, locales...) }
+
+func (e *v1Error) WithPublic(ok bool) Error {
+	err := e.withStack()
+	if ok {
+		err.public = publicYes
+	} else {
+		err.public = publicNo
+	}
+	return e.created(err)
+}
+
+func (e *v1Error) WithCorrelationID(id string) Error {
+	if id == "" {
+		id = NewCorrelationID()
+	}
+
+	err := e.withStack()
+	err.id = id
+
+	if e.stack != nil || e.raw != nil {
+		err.stack, err.raw, err.mode = e.stack, e.raw, e.mode
+	}
+	return e.created(err)
+}
+
+// CorrelationID - идентификатор корреляции первого слоя, где он указан
+func CorrelationID(err error) string {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*v1Error); ok && e.id != "" {
+			return e.id
+		}
+	}
+	return ""
+}
+
+// NewCorrelationID - случайный идентификатор из 16 шестнадцатеричных символов
+func NewCorrelationID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// ValidCorrelationID - годится ли идентификатор из запроса: до 64 символов из латинских букв, цифр и "-_.:"
+func ValidCorrelationID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isPublic - первая отметка в цепочке прототипов, без нее - есть ли у слоя HTTP-статус
+func (e *v1Error) isPublic() bool {
+	marks := publicMarks.byErr.Load().(map[*v1Error]bool)
+
+	for p := e; p != nil; p = p.proto {
+		switch p.public {
+		case publicYes:
+			return true
+		case publicNo:
+			return false
+		}
+
+		if ok, found := marks[p]; found {
+			return ok
+		}
+	}
+
+	for p := e; p != nil; p = p.proto {
+		if textStatus(p.text) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError - стандартная ошибка с таким HTTP-статусом, для остальных ErrInternal
+func statusError(code int) *v1Error {
+	for _, err := range []Error{
+		ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrNotAllowed, ErrNotAcceptable, ErrUnprocessable,
+		ErrInternal, ErrNotImplemented, ErrUnavailable,
+	} {
+		if e := err.(*v1Error); textStatus(e.text) == code {
+			return e
+		}
+	}
+	return ErrInternal.(*v1Error)
+}
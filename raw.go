@@ -0,0 +1,126 @@
+package errx
+
+import (
+	"bytes"
+	"debug/elf"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"sync"
+)
+
+// RawStack - стек в виде адресов вызовов, символизируется позже по файлу той же сборки
+type RawStack struct {
+	PCs     []uint64 // Адреса возврата, как их отдает runtime.Callers
+	BuildID string   // Go build ID бинарного файла
+	Module  string   // Главный модуль сборки и его версия, как "example.com/app@v1.2.3"
+	Base    uint64   // Адрес errx.callers в процессе, по нему вычисляется сдвиг загрузки
+
+	limit       int  // Глубина стека по настройкам при сборе, применяется при символизации
+	keepRuntime bool // Оставлять ли кадры runtime при символизации
+}
+
+// AnchorFunc - функция, по адресу которой в RawStack.Base вычисляется сдвиг загрузки
+var AnchorFunc = selfPkg + ".callers"
+
+var self struct {
+	once   sync.Once
+	build  string
+	module string
+	base   uint64
+}
+
+func init() {
+	self.base = uint64(reflect.ValueOf(callers).Pointer())
+}
+
+// BuildID - Go build ID текущего процесса, пустая строка, если его не удалось прочитать
+func BuildID() string {
+	self.once.Do(func() {
+		if exe, err := os.Executable(); err == nil {
+			self.build, _ = ReadBuildID(exe)
+		}
+
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" {
+			self.module = bi.Main.Path + "@" + bi.Main.Version
+		}
+	})
+	return self.build
+}
+
+// ReadBuildID - Go build ID из заметки .note.go.buildid в ELF файле с порядком байт самого файла
+func ReadBuildID(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sec := f.Section(".note.go.buildid")
+	if sec == nil {
+		return "", ErrNotFound.WithDetail("no Go build ID in %s", path)
+	}
+
+	data, err := sec.Data()
+	if err != nil {
+		return "", err
+	}
+
+	// Заметка ELF: размер имени, размер описания, тип 4, имя "Go" с выравниванием до 4 байт и описание
+	if len(data) < 16 {
+		return "", ErrBadRequest.WithDetail("short Go build ID note in %s", path)
+	}
+
+	name := int(f.ByteOrder.Uint32(data[0:]))
+	size := int(f.ByteOrder.Uint32(data[4:]))
+	kind := f.ByteOrder.Uint32(data[8:])
+	from := 12 + (name+3)&^3
+
+	if kind != 4 || name != 4 || !bytes.Equal(data[12:16], []byte("Go\x00\x00")) || from+size > len(data) {
+		return "", ErrBadRequest.WithDetail("unexpected Go build ID note in %s", path)
+	}
+
+	return string(data[from : from+size]), nil
+}
+
+// rawStack - адреса текущей горутины для упаковки вместо кадров
+func rawStack(pcs []uintptr, limit int, keepRuntime bool) *RawStack {
+	build := BuildID()
+	raw := &RawStack{
+		PCs:         make([]uint64, len(pcs)),
+		BuildID:     build,
+		Module:      self.module,
+		Base:        self.base,
+		limit:       limit,
+		keepRuntime: keepRuntime,
+	}
+
+	for i := range pcs {
+		raw.PCs[i] = uint64(pcs[i])
+	}
+	return raw
+}
+
+// local - собран ли стек этим же процессом, тогда его можно символизировать через runtime
+func (r *RawStack) local() bool {
+	return r.Base == self.base && r.BuildID == BuildID()
+}
+
+// symbolize - кадры стека, собранного этим же процессом
+func (r *RawStack) symbolize() []Frame {
+	pcs := make([]uintptr, len(r.PCs))
+	for i := range r.PCs {
+		pcs[i] = uintptr(r.PCs[i])
+	}
+	return framesOf(pcs, r.limit, r.keepRuntime)
+}
+
+// rawCallers - адреса стека без символизации с запасом в 8 адресов на кадры errx и runtime,
+// которые отбрасываются только при символизации
+func rawCallers(skip, limit int) []uintptr {
+	pcs := programCounters(skip+1, limit)
+	if limit > 0 && len(pcs) > limit+8 {
+		pcs = pcs[:limit+8]
+	}
+	return pcs
+}
@@ -0,0 +1,28 @@
+package errx
+
+// ErrPanic - паника, перехваченная Recover
+var ErrPanic = Register(ErrInternal.Derive("panic"))
+
+// Recover - перехват паники в defer: defer errx.Recover(&err).
+// Записывает в *errp ошибку ErrPanic со значением паники в детализации и стеком места паники,
+// если значение паники было ошибкой, она становится причиной. При errp == nil только оповещает подписчиков.
+func Recover(errp *error) {
+	rec := recover()
+
+	if rec == nil {
+		return
+	}
+
+	var err Error
+	if reason, ok := rec.(error); ok {
+		err = ErrPanic.WithReason(reason)
+	} else {
+		err = ErrPanic.WithDetail("%v", rec)
+	}
+
+	notify(EventRecover, err)
+
+	if errp != nil {
+		*errp = err
+	}
+}
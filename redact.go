@@ -0,0 +1,79 @@
+package errx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactedValue - замена значения отладки с чувствительным ключом
+const RedactedValue = "[REDACTED]"
+
+var redactKeys atomic.Value
+
+func init() {
+	SetRedactKeys("password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "apikey")
+}
+
+// SetRedactKeys - подстроки ключей отладки, чьи значения скрываются при выдаче наружу (без учета регистра)
+func SetRedactKeys(keys ...string) {
+	list := make([]string, len(keys))
+	for i := range keys {
+		list[i] = strings.ToLower(keys[i])
+	}
+	redactKeys.Store(list)
+}
+
+// Redacted - нужно ли скрывать значение отладки с таким ключом
+func Redacted(key string) bool {
+	key = strings.ToLower(key)
+	for _, sub := range redactKeys.Load().([]string) {
+		if strings.Contains(key, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact - копия представления всей цепочки со скрытыми чувствительными значениями отладки
+func Redact(v *View) *View {
+	if v == nil {
+		return nil
+	}
+
+	res := *v
+	res.Next = Redact(v.Next)
+
+	if v.Debug != nil {
+		res.Debug = make(map[string]string, len(v.Debug))
+		for key, val := range v.Debug {
+			if Redacted(key) {
+				val = RedactedValue
+			}
+			res.Debug[key] = val
+		}
+	}
+	return &res
+}
+
+// ExportRedacted - представление цепочки для выдачи наружу со скрытыми чувствительными значениями отладки, nil для nil
+func ExportRedacted(err error) *View { return Redact(export(err)) }
+
+// export - представление цепочки, сторонние слои по одному своим текстом, а цепочка errx под ними - через Export.
+// Обертка fmt.Errorf с %w включает в свой текст вывод %v цепочки errx с отладкой, он заменяется ее текстом.
+func export(err error) *View {
+	if err == nil {
+		return nil
+	}
+
+	if e, ok := err.(Error); ok {
+		return e.Export()
+	}
+
+	text, next := err.Error(), errors.Unwrap(err)
+	if e, ok := next.(Error); ok {
+		text = strings.Replace(text, fmt.Sprintf("%v", e), e.Error(), 1)
+	}
+	return &View{Text: text, Next: export(next)}
+}
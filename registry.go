@@ -0,0 +1,150 @@
+package errx
+
+import (
+	"errors"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Origin - место объявления зарегистрированной ошибки
+type Origin struct {
+	Package string
+	File    string
+	Line    int
+}
+
+// Collision - две разные зарегистрированные ошибки с одинаковым текстом
+type Collision struct {
+	Text   string
+	First  Origin
+	Second Origin
+}
+
+var registry = struct {
+	sync.RWMutex
+	byErr  map[*v1Error]Origin
+	byText map[string][]*v1Error
+	dups   []Collision
+}{
+	byErr:  make(map[*v1Error]Origin),
+	byText: make(map[string][]*v1Error),
+}
+
+// Define - создание и регистрация ошибки-шаблона (аналог New для глобальных переменных).
+// Запоминает пакет и место объявления, о совпадении текста с уже зарегистрированной сообщает в лог.
+func Define(text string) Error { return register(newErrorV1(text), 2) }
+
+// Register - регистрация уже созданной ошибки-шаблона.
+// Повторная регистрация ничего не меняет, при Unpack слои восстанавливаются по пакету и тексту.
+func Register(err Error) Error { return register(err, 2) }
+
+// Collisions - список найденных при регистрации коллизий текстов
+func Collisions() []Collision {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]Collision(nil), registry.dups...)
+}
+
+// Lookup - поиск зарегистрированной ошибки по пакету и тексту
+func Lookup(pkg, text string) (Error, bool) {
+	if e := lookup(pkg, text); e != nil {
+		return e, true
+	}
+	return nil, false
+}
+
+// Sentinel - ближайшая зарегистрированная ошибка среди прототипов внешнего слоя errx в цепочке
+func Sentinel(err error) (Error, Origin, bool) {
+	var e *v1Error
+	if !errors.As(err, &e) {
+		return nil, Origin{}, false
+	}
+
+	if p := e.sentinel(); p != nil {
+		org, _ := origin(p)
+		return p, org, true
+	}
+	return nil, Origin{}, false
+}
+
+func register(err Error, skip int) Error {
+	e, ok := err.(*v1Error)
+
+	if !ok {
+		return err
+	}
+
+	org := Origin{}
+	if pc, file, line, ok := runtime.Caller(skip); ok {
+		org.File = file
+		org.Line = line
+		org.Package = funcPackage(runtime.FuncForPC(pc).Name())
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.byErr[e]; ok {
+		return err
+	}
+
+	for _, prev := range registry.byText[e.text] {
+		dup := Collision{
+			Text:   e.text,
+			First:  registry.byErr[prev],
+			Second: org,
+		}
+		registry.dups = append(registry.dups, dup)
+		log.Printf("errx: duplicate error text %q: %s:%d (%s) and %s:%d (%s)",
+			dup.Text, dup.First.File, dup.First.Line, dup.First.Package,
+			dup.Second.File, dup.Second.Line, dup.Second.Package,
+		)
+	}
+
+	registry.byErr[e] = org
+	registry.byText[e.text] = append(registry.byText[e.text], e)
+	return err
+}
+
+func lookup(pkg, text string) *v1Error {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	list := registry.byText[text]
+
+	// Без пакета восстанавливаем только однозначные совпадения
+	if pkg == "" {
+		if len(list) == 1 {
+			return list[0]
+		}
+		return nil
+	}
+
+	for i := range list {
+		if registry.byErr[list[i]].Package == pkg {
+			return list[i]
+		}
+	}
+	return nil
+}
+
+func origin(e *v1Error) (Origin, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	org, ok := registry.byErr[e]
+	return org, ok
+}
+
+// funcPackage - путь пакета из полного имени функции вида "github.com/a/b.(*T).F"
+func funcPackage(name string) string {
+	dir := ""
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		dir, name = name[:i+1], name[i+1:]
+	}
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	return dir + name
+}
@@ -0,0 +1,346 @@
+package errx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ColorMode - режим раскраски при выводе в терминал
+type ColorMode int
+
+// Режимы раскраски
+const (
+	ColorAuto   ColorMode = iota // Цвет только для терминала и без переменной NO_COLOR
+	ColorAlways                  // Всегда
+	ColorNever                   // Никогда
+)
+
+// RenderOptions - настройки Render
+type RenderOptions struct {
+	Color   ColorMode
+	Width   int      // Ширина строки, 0 - по терминалу или COLUMNS, меньше 0 - без переноса
+	Modules []string // Модули приложения для выделения кадров, по умолчанию главный модуль сборки
+	NoStack bool     // Не выводить стек
+	Source  int      // Для кадров модулей приложения показывать столько строк исходника до и после, 0 - не показывать
+}
+
+// Стили ANSI
+const (
+	styleNone   = ""
+	styleReset  = "\x1b[0m"
+	styleText   = "\x1b[1;31m"
+	styleKey    = "\x1b[36m"
+	styleTree   = "\x1b[2m"
+	styleModule = "\x1b[1;33m"
+	styleStd    = "\x1b[2m"
+	styleFocus  = "\x1b[1m"
+)
+
+const defaultWidth = 100
+
+// Render - вывод цепочки в виде дерева для чтения в терминале.
+// Текст, ключи отладки и кадры раскрашиваются: стандартные пакеты приглушены, модули приложения выделены.
+// Ключи отладки выводятся по алфавиту и выровнены, длинные строки переносятся по словам с отступом дерева.
+// Чувствительные значения отладки скрываются (Redact).
+func Render(w io.Writer, err error, opts RenderOptions) error {
+	if err == nil {
+		return nil
+	}
+
+	r := &renderer{opts: opts, color: colorEnabled(w, opts.Color), width: opts.Width}
+
+	if r.width == 0 {
+		r.width = termWidth(w)
+	}
+
+	if r.opts.Modules == nil {
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Path != "" {
+			r.opts.Modules = []string{bi.Main.Path}
+		}
+	}
+
+	var outer []Frame
+	for i, v := 0, ExportRedacted(err); v != nil && i < 10; i, v = i+1, v.Next {
+		outer = r.layer(v, outer, i == 0)
+	}
+
+	_, err = w.Write(r.buf.Bytes())
+	return err
+}
+
+type renderer struct {
+	buf   bytes.Buffer
+	opts  RenderOptions
+	color bool
+	width int
+}
+
+type span struct {
+	text  string
+	style string
+}
+
+// layer - вывод слоя, кадры общие с внешним слоем сворачиваются, возвращает кадры слоя
+func (r *renderer) layer(v *View, outer []Frame, top bool) []Frame {
+	head := []span{{v.Text, styleText}}
+	if v.Detail != "" {
+		head = append(head, span{" (" + v.Detail + ")", styleNone})
+	}
+
+	if top {
+		r.line("> ", "  ", head)
+	} else {
+		r.line("|-> ", "|   ", head)
+	}
+
+	keys := v.Keys()
+	size := 0
+	for _, key := range keys {
+		if n := utf8.RuneCountInString(key); n > size {
+			size = n
+		}
+	}
+
+	for _, key := range keys {
+		pad := strings.Repeat(" ", size-utf8.RuneCountInString(key))
+		r.line("|   ", "|   "+strings.Repeat(" ", size+2), []span{{key, styleKey}, {":" + pad + " " + v.Debug[key], styleNone}})
+	}
+
+	frames := v.Frames
+	if frames == nil {
+		frames = parseFrames(v.Stack)
+	}
+
+	if r.opts.NoStack {
+		return frames
+	}
+
+	more := sharedFrames(frames, outer)
+	for _, f := range frames[:len(frames)-more] {
+		style := r.frameStyle(f)
+		r.line("|       ", "|         ", []span{{f.String(), style}})
+
+		if r.opts.Source > 0 && style == styleModule {
+			r.snippet(f)
+		}
+	}
+
+	if more > 0 {
+		r.line("|       ", "|         ", []span{{fmt.Sprintf("... %d more", more), styleStd}})
+	}
+	return frames
+}
+
+// snippet - строки исходника вокруг кадра, если файл доступен на диске
+func (r *renderer) snippet(f Frame) {
+	lines := Snippet(f, r.opts.Source)
+
+	if len(lines) == 0 {
+		return
+	}
+
+	size := len(strconv.Itoa(lines[len(lines)-1].Number))
+	for _, line := range lines {
+		num := strconv.Itoa(line.Number)
+		num = strings.Repeat(" ", size-len(num)) + num
+
+		text := line.Text
+		if text != "" {
+			text = " " + text
+		}
+
+		if line.Focus {
+			r.line("|         ", "|           "+strings.Repeat(" ", size+3), []span{{"> " + num + " |" + text, styleFocus}})
+		} else {
+			r.line("|         ", "|           "+strings.Repeat(" ", size+3), []span{{"  " + num + " |", styleStd}, {text, styleNone}})
+		}
+	}
+}
+
+func (r *renderer) frameStyle(f Frame) string {
+	if f.InModule(r.opts.Modules...) {
+		return styleModule
+	}
+
+	if isStd(f) {
+		return styleStd
+	}
+	return styleNone
+}
+
+// line - строка дерева с переносом по словам, next - префикс продолжения
+func (r *renderer) line(prefix, next string, spans []span) {
+	limit := -1
+	if r.width > 0 {
+		limit = r.width - utf8.RuneCountInString(prefix)
+		if limit < 20 {
+			limit = 20
+		}
+	}
+
+	lines := wrapSpans(spans, limit)
+	for i := range lines {
+		if i == 0 {
+			r.write(prefix, styleTree)
+		} else {
+			r.write(next, styleTree)
+		}
+
+		for _, s := range lines[i] {
+			r.write(s.text, s.style)
+		}
+		r.buf.WriteByte('\n')
+	}
+}
+
+func (r *renderer) write(text, style string) {
+	if !r.color || style == styleNone {
+		r.buf.WriteString(text)
+		return
+	}
+
+	r.buf.WriteString(style)
+	r.buf.WriteString(text)
+	r.buf.WriteString(styleReset)
+}
+
+// wrapSpans - перенос по словам, слово длиннее строки режется, limit < 0 - без переноса
+func wrapSpans(spans []span, limit int) [][]span {
+	if limit < 0 {
+		return [][]span{spans}
+	}
+
+	lines := [][]span{nil}
+	size := 0
+
+	for _, s := range spans {
+		for _, word := range splitWords(s.text) {
+			n := utf8.RuneCountInString(word)
+
+			// Пробелы в начале строки переноса не нужны
+			if size+n > limit && size > 0 {
+				lines = append(lines, nil)
+				size = 0
+				if word = strings.TrimLeft(word, " "); word == "" {
+					continue
+				}
+				n = utf8.RuneCountInString(word)
+			}
+
+			for n > limit {
+				cut := runeOffset(word, limit)
+				lines[len(lines)-1] = appendSpan(lines[len(lines)-1], span{word[:cut], s.style})
+				lines = append(lines, nil)
+				word, n = word[cut:], n-limit
+			}
+
+			lines[len(lines)-1] = appendSpan(lines[len(lines)-1], span{word, s.style})
+			size += n
+		}
+	}
+	return lines
+}
+
+// appendSpan - соседние куски одного стиля склеиваются, чтобы не плодить коды ANSI
+func appendSpan(line []span, s span) []span {
+	if n := len(line); n > 0 && line[n-1].style == s.style {
+		line[n-1].text += s.text
+		return line
+	}
+	return append(line, s)
+}
+
+// splitWords - разбиение на слова, каждое с предшествующими пробелами
+func splitWords(text string) []string {
+	var list []string
+
+	for len(text) > 0 {
+		i := 0
+		for i < len(text) && text[i] == ' ' {
+			i++
+		}
+
+		j := strings.IndexByte(text[i:], ' ')
+		if j < 0 {
+			j = len(text)
+		} else {
+			j += i
+		}
+
+		list = append(list, text[:j])
+		text = text[j:]
+	}
+	return list
+}
+
+func runeOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
+
+func colorEnabled(w io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// termWidth - ширина терминала, затем COLUMNS, ширина по умолчанию только для терминала, иначе без переноса
+func termWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if ok {
+		if n := fdWidth(f.Fd()); n > 0 {
+			return n
+		}
+	}
+
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+
+	if fi, err := f.Stat(); ok && err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		return defaultWidth
+	}
+	return -1
+}
+
+// isStd - стандартный ли пакет кадра: в первом элементе полного пути нет точки.
+// После Unpack без путей модулей от пакета остается последний элемент, по нему стандартный пакет не отличить,
+// такие кадры считаются нестандартными. Исключение - runtime, его кадры есть в любом стеке.
+func isStd(f Frame) bool {
+	pkg := f.Package()
+	if f.Module != "" || pkg == "main" {
+		return false
+	}
+
+	if i := strings.IndexByte(pkg, '/'); i >= 0 {
+		return !strings.Contains(pkg[:i], ".")
+	}
+	return pkg == "runtime" || filepath.IsAbs(f.File)
+}
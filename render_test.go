@@ -0,0 +1,183 @@
+package errx_test
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fbs "github.com/google/flatbuffers/go"
+	"github.com/shestakovda/errx"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталоны в testdata")
+
+// golden - сравнение с эталоном из testdata, с флагом -update эталон перезаписывается
+func (s *InterfaceSuite) golden(name string, got []byte) {
+	file := filepath.Join("testdata", name)
+
+	if *update {
+		s.Require().NoError(os.WriteFile(file, got, 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	s.Require().NoError(err)
+	s.Equal(string(want), string(got))
+}
+
+// sampleError - ошибка с фиксированным стеком, не зависящая от машины и версии Go
+func sampleError() errx.Error {
+	model := &errx.ErrorModelT{
+		Text:   "user not found",
+		Detail: "id 42 is missing from the users table of the primary database",
+		Stack: []string{
+			"service.go:42 -> app.(*Service).Find()",
+			"handler.go:17 -> app.(*Handler).ServeHTTP()",
+			"conn.go:310 -> pgx.(*Conn).Query()",
+			"server.go:2220 -> http.HandlerFunc.ServeHTTP()",
+			"asm_amd64.s:1700 -> runtime.goexit()",
+		},
+		Debug: []*errx.KeyValueT{
+			{Key: "user", Value: "42"},
+			{Key: "request_id", Value: "7f3c2a"},
+		},
+		Next: &errx.ErrorModelT{
+			Text:  "connection refused",
+			Stack: []string{"conn.go:88 -> pgx.connect()"},
+		},
+	}
+
+	return unpackModel(model)
+}
+
+// sourceError - ошибка с кадрами, исходники которых лежат в testdata
+func sourceError() errx.Error {
+	return unpackModel(&errx.ErrorModelT{
+		Text: "user not found",
+		Stack: []string{
+			"testdata/service.go:12 -> app.(*Service).Find()",
+			"testdata/missing.go:7 -> app.(*Handler).ServeHTTP()",
+			"testdata/service.go:1 -> app.init()",
+			"testdata/service.go:99 -> app.(*Service).Gone()",
+			"server.go:2220 -> http.HandlerFunc.ServeHTTP()",
+		},
+	})
+}
+
+func unpackModel(model *errx.ErrorModelT) errx.Error {
+	buf := fbs.NewBuilder(1024)
+	buf.Finish(model.Pack(buf))
+	return errx.Unpack(buf.FinishedBytes())
+}
+
+func (s *InterfaceSuite) TestRender() {
+	cases := []struct {
+		name string
+		opts errx.RenderOptions
+	}{
+		{"render_plain.golden", errx.RenderOptions{Color: errx.ColorNever, Width: -1}},
+		{"render_color.golden", errx.RenderOptions{Color: errx.ColorAlways, Width: -1}},
+		{"render_narrow.golden", errx.RenderOptions{Color: errx.ColorNever, Width: 40}},
+		{"render_nostack.golden", errx.RenderOptions{Color: errx.ColorAlways, Width: 60, NoStack: true}},
+	}
+
+	for _, c := range cases {
+		var buf bytes.Buffer
+		c.opts.Modules = []string{"example.com/app"}
+		s.Require().NoError(errx.Render(&buf, sampleError(), c.opts))
+		s.golden(c.name, buf.Bytes())
+	}
+
+	// В буфер без явного режима цвет не выводится, перенос по COLUMNS
+	var buf bytes.Buffer
+	s.T().Setenv("COLUMNS", "40")
+	s.Require().NoError(errx.Render(&buf, sampleError(), errx.RenderOptions{Modules: []string{"example.com/app"}}))
+	s.golden("render_narrow.golden", buf.Bytes())
+
+	// В файл, который не терминал, без COLUMNS строки не переносятся
+	file, err := os.CreateTemp(s.T().TempDir(), "render")
+	s.Require().NoError(err)
+	defer file.Close()
+
+	s.T().Setenv("COLUMNS", "")
+	s.Require().NoError(errx.Render(file, errx.New(strings.Repeat("word ", 40)), errx.RenderOptions{}))
+	got, err := os.ReadFile(file.Name())
+	s.Require().NoError(err)
+	s.Equal(1, strings.Count(string(got), "\n"))
+
+	s.NoError(errx.Render(&buf, nil, errx.RenderOptions{}))
+}
+
+func (s *InterfaceSuite) TestRenderSource() {
+	opts := errx.RenderOptions{Color: errx.ColorNever, Width: -1, Modules: []string{"example.com/app"}, Source: 2}
+
+	// Недоступные файлы и строки за пределами файла пропускаются без ошибок
+	var buf bytes.Buffer
+	s.Require().NoError(errx.Render(&buf, sourceError(), opts))
+	s.golden("render_source.golden", buf.Bytes())
+
+	buf.Reset()
+	opts.Color = errx.ColorAlways
+	s.Require().NoError(errx.Render(&buf, sourceError(), opts))
+	s.golden("render_source_color.golden", buf.Bytes())
+
+	// Без опции исходники не читаются
+	buf.Reset()
+	opts.Source = 0
+	s.Require().NoError(errx.Render(&buf, sourceError(), opts))
+	s.NotContains(buf.String(), "ErrNotFound")
+}
+
+func (s *InterfaceSuite) TestReport() {
+	errx.SetLinkOptions(errx.LinkOptions{Template: "https://git.example.com/{module}/blob/{version}/{path}#L{line}"})
+	defer errx.SetLinkOptions(errx.LinkOptions{})
+
+	err := reportError()
+
+	s.golden("report.md", []byte(errx.Report(err, errx.ReportMarkdown)))
+	s.golden("report.txt", []byte(errx.Report(err, errx.ReportText)))
+	s.golden("report_line.golden", []byte(errx.Report(err, errx.ReportLine)))
+	s.Equal(errx.Report(err, errx.ReportMarkdown), err.Export().Markdown())
+
+	// Разметка в тексте ошибки выводится как есть
+	s.Contains(errx.Report(errx.New("# user_id *42* [x] `y`"), errx.ReportMarkdown), "## \\# user\\_id \\*42\\* \\[x\\] \\`y\\`\n")
+
+	// Ключи выравниваются по числу символов, а не байт
+	keyed := unpackModel(&errx.ErrorModelT{Text: "user", Debug: []*errx.KeyValueT{{Key: "id", Value: "42"}, {Key: "имя", Value: "bob"}}})
+	s.Equal("user\n    id:  42\n    имя: bob\n", errx.Report(keyed, errx.ReportText))
+
+	s.Empty(errx.Report(nil, errx.ReportText))
+	s.Equal("EOF", errx.Report(io.EOF, errx.ReportLine))
+}
+
+// reportError - ошибка для отчетов: общие со внешним слоем кадры, ссылки, значения для экранирования и скрытия
+func reportError() errx.Error {
+	return unpackModel(&errx.ErrorModelT{
+		Text:        "user not found",
+		Detail:      "id 42 is <missing>",
+		Fingerprint: "0123456789abcdef",
+		Stack: []string{
+			"service.go:42 -> app.(*Service).Find()",
+			"handler.go:17 -> app.(*Handler).ServeHTTP()",
+			"server.go:2220 -> http.HandlerFunc.ServeHTTP()",
+		},
+		FramePaths: []string{"example.com/app@v1.2.3/internal/service.go", "", ""},
+		Debug: []*errx.KeyValueT{
+			{Key: "query", Value: "SELECT *\nFROM users | WHERE id = 42"},
+			{Key: "token", Value: "secret"},
+			{Key: "user", Value: "42"},
+		},
+		Next: &errx.ErrorModelT{
+			Text: "connection refused",
+			Stack: []string{
+				"conn.go:88 -> pgx.connect()",
+				"handler.go:17 -> app.(*Handler).ServeHTTP()",
+				"server.go:2220 -> http.HandlerFunc.ServeHTTP()",
+			},
+			Debug: []*errx.KeyValueT{{Key: "addr", Value: "db:5432"}},
+		},
+	})
+}
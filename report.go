@@ -0,0 +1,203 @@
+package errx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ReportFormat - формат отчета об ошибке
+type ReportFormat int
+
+// Форматы отчета
+const (
+	ReportMarkdown ReportFormat = iota // Для трекеров задач: заголовок на слой, отладка таблицей, стек блоком кода
+	ReportText                         // Простой текст для писем
+	ReportLine                         // Одна строка для поиска по логам
+)
+
+// Report - отчет о цепочке в нужном формате, чувствительные значения отладки скрываются (Redact)
+func Report(err error, format ReportFormat) string {
+	if err == nil {
+		return ""
+	}
+	return ExportRedacted(err).report(format)
+}
+
+// Markdown - отчет о цепочке в Markdown, чувствительные значения отладки скрываются (Redact)
+func (v *View) Markdown() string { return Redact(v).report(ReportMarkdown) }
+
+func (v *View) report(format ReportFormat) string {
+	var buf strings.Builder
+	var outer []Frame
+
+	for i, cur := 0, v; cur != nil && i < 10; i, cur = i+1, cur.Next {
+		frames := cur.Frames
+		if frames == nil {
+			frames = parseFrames(cur.Stack)
+		}
+
+		more := sharedFrames(frames, outer)
+		own := frames[:len(frames)-more]
+
+		switch format {
+		case ReportMarkdown:
+			cur.markdownLayer(&buf, i, own, more)
+		case ReportText:
+			cur.textLayer(&buf, i, own, more)
+		case ReportLine:
+			cur.lineLayer(&buf, i)
+		}
+		outer = frames
+	}
+
+	if format == ReportLine && v.Fingerprint != "" {
+		fmt.Fprintf(&buf, " fp=%s", v.Fingerprint)
+	}
+
+	return buf.String()
+}
+
+func (v *View) markdownLayer(buf *strings.Builder, i int, frames []Frame, more int) {
+	if i == 0 {
+		fmt.Fprintf(buf, "## %s\n\n", mdInline(v.Text))
+	} else {
+		fmt.Fprintf(buf, "\n### Caused by: %s\n\n", mdInline(v.Text))
+	}
+
+	if v.Detail != "" {
+		fmt.Fprintf(buf, "%s\n\n", mdInline(v.Detail))
+	}
+
+	if v.Fingerprint != "" {
+		fmt.Fprintf(buf, "Fingerprint: `%s`\n\n", v.Fingerprint)
+	}
+
+	if len(v.Debug) > 0 {
+		buf.WriteString("| Key | Value |\n| --- | --- |\n")
+		for _, key := range v.Keys() {
+			fmt.Fprintf(buf, "| %s | %s |\n", mdCell(key), mdCell(v.Debug[key]))
+		}
+		buf.WriteString("\n")
+	}
+
+	if len(frames) == 0 && more == 0 {
+		return
+	}
+
+	// Блок кода не должен закрываться строкой стека
+	fence := "```"
+	for _, f := range frames {
+		for strings.Contains(f.String(), fence) {
+			fence += "`"
+		}
+	}
+
+	fmt.Fprintf(buf, "%stext\n", fence)
+	for _, f := range frames {
+		fmt.Fprintf(buf, "%s\n", f)
+	}
+	if more > 0 {
+		fmt.Fprintf(buf, "... %d more\n", more)
+	}
+	fmt.Fprintf(buf, "%s\n", fence)
+
+	first := true
+	for _, f := range frames {
+		if link := f.Link(); link != "" {
+			if first {
+				buf.WriteString("\nSource:\n\n")
+				first = false
+			}
+			fmt.Fprintf(buf, "- [`%s`](%s)\n", f, link)
+		}
+	}
+}
+
+func (v *View) textLayer(buf *strings.Builder, i int, frames []Frame, more int) {
+	if i > 0 {
+		buf.WriteString("Caused by: ")
+	}
+
+	buf.WriteString(v.Text)
+	if v.Detail != "" {
+		fmt.Fprintf(buf, " (%s)", v.Detail)
+	}
+	buf.WriteString("\n")
+
+	keys := v.Keys()
+	size := 0
+	for _, key := range keys {
+		if n := utf8.RuneCountInString(key); n > size {
+			size = n
+		}
+	}
+
+	// Многострочные значения продолжаются с отступом под первой строкой значения
+	indent := "\n" + strings.Repeat(" ", size+6)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "    %-*s %s\n", size+1, key+":", strings.ReplaceAll(v.Debug[key], "\n", indent))
+	}
+
+	for _, f := range frames {
+		fmt.Fprintf(buf, "    at %s\n", f)
+	}
+
+	if more > 0 {
+		fmt.Fprintf(buf, "    ... %d more\n", more)
+	}
+}
+
+func (v *View) lineLayer(buf *strings.Builder, i int) {
+	if i > 0 {
+		buf.WriteString(": ")
+	}
+
+	buf.WriteString(oneLine(v.Text))
+	if v.Detail != "" {
+		fmt.Fprintf(buf, " (%s)", oneLine(v.Detail))
+	}
+
+	if len(v.Debug) == 0 {
+		return
+	}
+
+	buf.WriteString(" [")
+	for j, key := range v.Keys() {
+		if j > 0 {
+			buf.WriteString(" ")
+		}
+		fmt.Fprintf(buf, "%s=%s", lineValue(key), lineValue(v.Debug[key]))
+	}
+	buf.WriteString("]")
+}
+
+// mdEscapes - замены для текста Markdown: разметка и HTML выводятся как есть
+var mdEscapes = []string{
+	"<", "&lt;", ">", "&gt;",
+	"\\", "\\\\", "*", "\\*", "_", "\\_", "#", "\\#", "[", "\\[", "]", "\\]", "`", "\\`",
+}
+
+var (
+	mdInlineReplacer = strings.NewReplacer(append([]string{"\r", "", "\n", " "}, mdEscapes...)...)
+	mdCellReplacer   = strings.NewReplacer(append([]string{"\r", "", "\n", "<br>", "|", "\\|"}, mdEscapes...)...)
+)
+
+// mdInline - текст для заголовка или абзаца Markdown в одну строку
+func mdInline(s string) string { return mdInlineReplacer.Replace(s) }
+
+// mdCell - текст для ячейки таблицы Markdown
+func mdCell(s string) string { return mdCellReplacer.Replace(s) }
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(s)
+}
+
+// lineValue - значение в кавычках, если без них его не отличить от соседних
+func lineValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=[]") {
+		return strconv.Quote(s)
+	}
+	return s
+}
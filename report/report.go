@@ -0,0 +1,232 @@
+package report
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shestakovda/errx"
+)
+
+// Entry - запись для приемников: полный отчет об ошибке или сводка подавленных
+type Entry struct {
+	Time        time.Time
+	Since       time.Time // Начало окна, в котором считались повторы
+	Fingerprint string
+	Err         error // Ошибка для полного отчета, nil для сводки
+	Suppressed  int   // Сколько похожих ошибок подавлено, только для сводки
+}
+
+// Summary - является ли запись сводкой подавленных ошибок
+func (e Entry) Summary() bool { return e.Err == nil }
+
+// Message - однострочное сообщение записи
+func (e Entry) Message() string {
+	if e.Summary() {
+		return "suppressed " + groupDigits(e.Suppressed) + " similar"
+	}
+	return e.Err.Error()
+}
+
+// Sink - приемник отчетов
+type Sink interface {
+	Write(e Entry) error
+}
+
+// Options - настройки Reporter
+type Options struct {
+	Limit  int              // Полных отчетов на отпечаток за окно, по умолчанию 10
+	Window time.Duration    // Длина окна, по умолчанию минута
+	Sinks  []Sink           // Приемники, получают все записи по порядку
+	Now    func() time.Time // Часы, по умолчанию time.Now
+
+	// Период фонового Flush, чтобы сводки не терялись, когда ошибка перестала повторяться.
+	// По умолчанию 0 - сводки только при Report, Flush и Close.
+	Interval time.Duration
+}
+
+// Reporter - отправка ошибок в приемники с дедупликацией по errx.Fingerprint
+type Reporter struct {
+	opts    Options
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time // Время последнего удаления закончившихся окон
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+type bucket struct {
+	since      time.Time
+	count      int
+	suppressed int
+}
+
+// New - создание Reporter
+func New(opts Options) *Reporter {
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	r := &Reporter{
+		opts:    opts,
+		buckets: make(map[string]*bucket),
+		swept:   opts.Now(),
+	}
+
+	if opts.Interval > 0 {
+		r.stop, r.done = make(chan struct{}), make(chan struct{})
+		go r.loop()
+	}
+	return r
+}
+
+// Close - остановка фонового Flush и отправка оставшихся сводок
+func (r *Reporter) Close() error {
+	r.once.Do(func() {
+		if r.stop != nil {
+			close(r.stop)
+			<-r.done
+		}
+	})
+	return r.Flush()
+}
+
+func (r *Reporter) loop() {
+	defer close(r.done)
+
+	tick := time.NewTicker(r.opts.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-tick.C:
+			// Ошибки приемников в фоне вернуть некому
+			_ = r.Flush()
+		}
+	}
+}
+
+// Report - отправка ошибки в приемники, возвращает первую ошибку приемников.
+// Первые Limit ошибок с одним отпечатком за окно отправляются полностью, остальные только считаются.
+// С началом нового окна сначала отправляется сводка подавленных в прошлом.
+// Раз в окно закончившиеся окна всех отпечатков удаляются со сводками, поэтому память не растет.
+func (r *Reporter) Report(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	now := r.opts.Now()
+	fp := errx.Fingerprint(err)
+	list := make([]Entry, 0, 2)
+
+	r.mu.Lock()
+	if now.Sub(r.swept) >= r.opts.Window {
+		list, r.swept = r.expire(now, list), now
+	}
+
+	b := r.buckets[fp]
+
+	if b != nil && now.Sub(b.since) >= r.opts.Window {
+		if b.suppressed > 0 {
+			list = append(list, Entry{Time: now, Since: b.since, Fingerprint: fp, Suppressed: b.suppressed})
+		}
+		b = nil
+	}
+
+	if b == nil {
+		b = &bucket{since: now}
+		r.buckets[fp] = b
+	}
+
+	if b.count++; b.count <= r.opts.Limit {
+		list = append(list, Entry{Time: now, Since: b.since, Fingerprint: fp, Err: err})
+	} else {
+		b.suppressed++
+	}
+	r.mu.Unlock()
+
+	return r.write(list)
+}
+
+// Flush - отправка сводок по всем подавленным ошибкам и удаление закончившихся окон
+func (r *Reporter) Flush() error {
+	now := r.opts.Now()
+	list := make([]Entry, 0, 1)
+
+	r.mu.Lock()
+	list, r.swept = r.expire(now, list), now
+
+	for _, fp := range r.sorted() {
+		if b := r.buckets[fp]; b.suppressed > 0 {
+			list = append(list, Entry{Time: now, Since: b.since, Fingerprint: fp, Suppressed: b.suppressed})
+			b.suppressed = 0
+		}
+	}
+	r.mu.Unlock()
+
+	return r.write(list)
+}
+
+// expire - удаление закончившихся окон со сводками подавленных в них, вызывается под блокировкой
+func (r *Reporter) expire(now time.Time, list []Entry) []Entry {
+	for _, fp := range r.sorted() {
+		b := r.buckets[fp]
+
+		if now.Sub(b.since) < r.opts.Window {
+			continue
+		}
+
+		if b.suppressed > 0 {
+			list = append(list, Entry{Time: now, Since: b.since, Fingerprint: fp, Suppressed: b.suppressed})
+		}
+		delete(r.buckets, fp)
+	}
+	return list
+}
+
+// sorted - отпечатки по алфавиту, чтобы сводки шли в одном порядке
+func (r *Reporter) sorted() []string {
+	list := make([]string, 0, len(r.buckets))
+	for fp := range r.buckets {
+		list = append(list, fp)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (r *Reporter) write(list []Entry) (err error) {
+	for i := range list {
+		for _, sink := range r.opts.Sinks {
+			if exc := sink.Write(list[i]); exc != nil && err == nil {
+				err = exc
+			}
+		}
+	}
+	return err
+}
+
+// groupDigits - число с разделителем разрядов: 49990 -> 49,990
+func groupDigits(n int) string {
+	s := strconv.Itoa(n)
+	res := make([]byte, 0, len(s)+len(s)/3)
+
+	for i := range s {
+		if i > 0 && s[i-1] != '-' && (len(s)-i)%3 == 0 {
+			res = append(res, ',')
+		}
+		res = append(res, s[i])
+	}
+	return string(res)
+}
@@ -0,0 +1,208 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/report"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestReport(t *testing.T) {
+	suite.Run(t, new(ReportSuite))
+}
+
+type ReportSuite struct {
+	suite.Suite
+	now time.Time
+}
+
+type memSink struct {
+	list []report.Entry
+}
+
+func (s *memSink) Write(e report.Entry) error {
+	s.list = append(s.list, e)
+	return nil
+}
+
+func (s *ReportSuite) SetupTest() {
+	s.now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *ReportSuite) clock() time.Time { return s.now }
+
+func failA() error { return errx.ErrUnavailable.WithDetail("db %d", 1) }
+func failB() error { return errx.ErrNotFound.WithDetail("key %d", 2) }
+
+func (s *ReportSuite) TestLimit() {
+	sink := new(memSink)
+	rep := report.New(report.Options{Limit: 2, Window: time.Minute, Sinks: []report.Sink{sink}, Now: s.clock})
+
+	for i := 0; i < 5000; i++ {
+		s.NoError(rep.Report(failA()))
+	}
+	s.NoError(rep.Report(failB()))
+	s.NoError(rep.Report(nil))
+
+	if s.Len(sink.list, 3) {
+		s.False(sink.list[0].Summary())
+		s.False(sink.list[1].Summary())
+		s.True(errx.Is(sink.list[2].Err, errx.ErrNotFound))
+		s.Equal(errx.Fingerprint(failA()), sink.list[0].Fingerprint)
+	}
+
+	// Новое окно начинается со сводки по прошлому
+	s.now = s.now.Add(time.Minute)
+	s.NoError(rep.Report(failA()))
+
+	if s.Len(sink.list, 5) {
+		s.True(sink.list[3].Summary())
+		s.Equal(4998, sink.list[3].Suppressed)
+		s.Equal("suppressed 4,998 similar", sink.list[3].Message())
+		s.Equal(s.now.Add(-time.Minute), sink.list[3].Since)
+		s.False(sink.list[4].Summary())
+	}
+
+	s.NoError(rep.Report(failA()))
+	s.NoError(rep.Report(failA()))
+	s.NoError(rep.Flush())
+
+	if s.Len(sink.list, 7) {
+		s.Equal(1, sink.list[6].Suppressed)
+	}
+
+	// Повторная сводка пустой не бывает
+	s.NoError(rep.Flush())
+	s.Len(sink.list, 7)
+}
+
+func (s *ReportSuite) TestExpire() {
+	sink := new(memSink)
+	rep := report.New(report.Options{Limit: 1, Window: time.Minute, Sinks: []report.Sink{sink}, Now: s.clock})
+
+	s.NoError(rep.Report(failA()))
+	s.NoError(rep.Report(failA()))
+
+	// Сводка по ошибке, которая больше не повторяется, уходит с первой ошибкой нового окна
+	s.now = s.now.Add(time.Minute)
+	s.NoError(rep.Report(failB()))
+
+	if s.Len(sink.list, 3) {
+		s.True(sink.list[1].Summary())
+		s.Equal(errx.Fingerprint(failA()), sink.list[1].Fingerprint)
+		s.True(errx.Is(sink.list[2].Err, errx.ErrNotFound))
+	}
+
+	// Окно уже удалено, поэтому повторной сводки нет, а новая ошибка снова отправляется полностью
+	s.NoError(rep.Flush())
+	s.NoError(rep.Report(failA()))
+	s.Len(sink.list, 4)
+}
+
+// lockedSink - приемник для фонового Flush
+type lockedSink struct {
+	mu   sync.Mutex
+	list []report.Entry
+}
+
+func (s *lockedSink) Write(e report.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list = append(s.list, e)
+	return nil
+}
+
+func (s *lockedSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.list)
+}
+
+func (s *ReportSuite) TestInterval() {
+	sink := new(lockedSink)
+	rep := report.New(report.Options{Limit: 1, Interval: time.Millisecond, Sinks: []report.Sink{sink}})
+
+	s.NoError(rep.Report(failA()))
+	s.NoError(rep.Report(failA()))
+	s.Eventually(func() bool { return sink.len() == 2 }, time.Second, time.Millisecond)
+
+	s.NoError(rep.Close())
+	s.NoError(rep.Close())
+
+	if s.Len(sink.list, 2) {
+		s.Equal(1, sink.list[1].Suppressed)
+	}
+}
+
+func (s *ReportSuite) TestWriter() {
+	var buf bytes.Buffer
+	rep := report.New(report.Options{Limit: 1, Sinks: []report.Sink{report.WriterSink(&buf)}, Now: s.clock})
+
+	s.NoError(rep.Report(failA()))
+	s.NoError(rep.Report(failA()))
+	s.NoError(rep.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	s.True(strings.HasPrefix(lines[0], "2020-01-01T00:00:00.000Z errx ["+errx.Fingerprint(failA())+"]: > 503 Service Unavailable (db 1)"))
+	s.Equal("2020-01-01T00:00:00.000Z errx ["+errx.Fingerprint(failA())+"]: suppressed 1 similar since 2020-01-01T00:00:00.000Z", lines[len(lines)-1])
+}
+
+func (s *ReportSuite) TestSlog() {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	rep := report.New(report.Options{Limit: 1, Sinks: []report.Sink{report.SlogSink(log)}, Now: s.clock})
+
+	s.NoError(rep.Report(failB()))
+	s.NoError(rep.Report(failB()))
+	s.NoError(rep.Flush())
+
+	dec := json.NewDecoder(&buf)
+	full := make(map[string]interface{})
+	summary := make(map[string]interface{})
+	s.NoError(dec.Decode(&full))
+	s.NoError(dec.Decode(&summary))
+
+	s.Equal("ERROR", full["level"])
+	s.Equal("404 Not Found", full["msg"])
+	s.Contains(full["error"], "> 404 Not Found (key 2)")
+	s.Equal("WARN", summary["level"])
+	s.Equal("suppressed 1 similar", summary["msg"])
+	s.Equal(float64(1), summary["suppressed"])
+	s.Equal(errx.Fingerprint(failB()), summary["fingerprint"])
+}
+
+func (s *ReportSuite) TestJournal() {
+	dir, err := os.MkdirTemp("", "errx")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	addr := filepath.Join(dir, "journal")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	exp := errx.ErrInternal.WithReason(failB())
+	rep := report.New(report.Options{Sinks: []report.Sink{report.JournalSink(addr)}, Now: s.clock})
+	s.NoError(rep.Report(exp))
+
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	s.Require().NoError(err)
+	msg := string(buf[:n])
+
+	s.Contains(msg, "PRIORITY=3\n")
+	s.Contains(msg, "MESSAGE=500 Internal Server Error\n")
+	s.Contains(msg, "ERRX_DETAIL\n")
+	s.Contains(msg, "|-> 404 Not Found (key 2)")
+	s.Contains(msg, "ERRX_FINGERPRINT="+errx.Fingerprint(exp)+"\n")
+}
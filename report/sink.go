@@ -0,0 +1,124 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+)
+
+// JournalSocket - сокет нативного протокола systemd-journald
+const JournalSocket = "/run/systemd/journal/socket"
+
+// WriterSink - приемник, пишущий полные отчеты в формате %+v, а сводки одной строкой
+func WriterSink(w io.Writer) Sink { return &writerSink{w: w} }
+
+type writerSink struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (s *writerSink) Write(e Entry) (err error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if e.Summary() {
+		_, err = fmt.Fprintf(s.w, "%s errx [%s]: %s since %s\n",
+			e.Time.Format(timeFormat), e.Fingerprint, e.Message(), e.Since.Format(timeFormat))
+	} else {
+		_, err = fmt.Fprintf(s.w, "%s errx [%s]: %+v\n", e.Time.Format(timeFormat), e.Fingerprint, e.Err)
+	}
+	return err
+}
+
+// SlogSink - приемник в slog, полные отчеты с уровнем Error, сводки с уровнем Warn
+func SlogSink(log *slog.Logger) Sink { return slogSink{log: log} }
+
+type slogSink struct {
+	log *slog.Logger
+}
+
+func (s slogSink) Write(e Entry) error {
+	if e.Summary() {
+		s.log.LogAttrs(context.Background(), slog.LevelWarn, e.Message(),
+			slog.String("fingerprint", e.Fingerprint),
+			slog.Int("suppressed", e.Suppressed),
+			slog.Time("since", e.Since),
+		)
+	} else {
+		s.log.LogAttrs(context.Background(), slog.LevelError, e.Message(),
+			slog.String("fingerprint", e.Fingerprint),
+			slog.String("error", fmt.Sprintf("%+v", e.Err)),
+		)
+	}
+	return nil
+}
+
+// JournalSink - приемник в systemd-journald по нативному протоколу, addr по умолчанию JournalSocket
+func JournalSink(addr string) Sink {
+	if addr == "" {
+		addr = JournalSocket
+	}
+	return &journalSink{addr: addr}
+}
+
+type journalSink struct {
+	sync.Mutex
+	addr string
+	conn net.Conn
+}
+
+func (s *journalSink) Write(e Entry) (err error) {
+	var buf bytes.Buffer
+
+	if e.Summary() {
+		journalField(&buf, "PRIORITY", "4")
+		journalField(&buf, "MESSAGE", e.Message())
+		journalField(&buf, "ERRX_SUPPRESSED", fmt.Sprint(e.Suppressed))
+	} else {
+		journalField(&buf, "PRIORITY", "3")
+		journalField(&buf, "MESSAGE", e.Message())
+		journalField(&buf, "ERRX_DETAIL", fmt.Sprintf("%+v", e.Err))
+	}
+	journalField(&buf, "ERRX_FINGERPRINT", e.Fingerprint)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.conn == nil {
+		if s.conn, err = net.Dial("unixgram", s.addr); err != nil {
+			s.conn = nil
+			return err
+		}
+	}
+
+	if _, err = s.conn.Write(buf.Bytes()); err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// journalField - поле нативного протокола, многострочные значения передаются с длиной
+func journalField(buf *bytes.Buffer, key, val string) {
+	buf.WriteString(key)
+
+	if !strings.ContainsRune(val, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(val)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(val)))
+	buf.WriteString(val)
+	buf.WriteByte('\n')
+}
+
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
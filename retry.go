@@ -0,0 +1,84 @@
+package errx
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+const (
+	retryUnknown byte = iota
+	retryYes
+	retryNo
+)
+
+// IsRetryable - можно ли повторить операцию, завершившуюся ошибкой.
+// Решение принимает первый сверху слой с известной классификацией: явный WithRetryable/WithRetryAfter,
+// таймаут сети или системного вызова, ECONNRESET, ECONNABORTED или наследник ErrUnavailable. Отмена и истечение контекста не повторяются.
+// Ветки errors.Join проверяются по порядку. Если ничего не подошло, возвращает false.
+func IsRetryable(err error) bool { return retryClass(err) == retryYes }
+
+// retryClass - первая известная классификация в цепочке, включая ветки составных ошибок
+func retryClass(err error) byte {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if res := classifyRetry(err); res != retryUnknown {
+			return res
+		}
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range multi.Unwrap() {
+				if res := retryClass(e); res != retryUnknown {
+					return res
+				}
+			}
+			return retryUnknown
+		}
+	}
+	return retryUnknown
+}
+
+// RetryAfter - рекомендуемая пауза перед повтором из первого слоя, где она указана
+func RetryAfter(err error) (time.Duration, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*v1Error); ok && e.after > 0 {
+			return e.after, true
+		}
+	}
+	return 0, false
+}
+
+func classifyRetry(err error) byte {
+	// context.DeadlineExceeded тоже net.Error с Timeout, но повтор с тем же контекстом бессмыслен
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return retryNo
+	}
+
+	switch e := err.(type) {
+	case *v1Error:
+		if e.retry != retryUnknown {
+			return e.retry
+		}
+
+		if e.after > 0 {
+			return retryYes
+		}
+
+		for p := e; p != nil; p = p.proto {
+			if Error(p) == ErrUnavailable {
+				return retryYes
+			}
+		}
+	case syscall.Errno:
+		// Errno тоже net.Error, поэтому проверяется раньше: таймауты (ETIMEDOUT, EAGAIN) и разрывы соединения
+		if e.Timeout() || e == syscall.ECONNRESET || e == syscall.ECONNABORTED {
+			return retryYes
+		}
+	case net.Error:
+		if e.Timeout() {
+			return retryYes
+		}
+	}
+	return retryUnknown
+}
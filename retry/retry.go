@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/shestakovda/errx"
+)
+
+// ErrAttempts - итоговая ошибка неудачных повторов, причины попыток лежат в Debug
+var ErrAttempts = errx.Define("retry attempts failed")
+
+// Clock - источник таймеров, подменяется в тестах
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// Policy - параметры повторов с экспоненциальной задержкой
+type Policy struct {
+	Attempts int            // Максимум попыток, по умолчанию 3
+	Delay    time.Duration  // Пауза перед первым повтором, по умолчанию 100ms
+	MaxDelay time.Duration  // Верхняя граница паузы, 0 - без ограничения
+	Factor   float64        // Множитель паузы, по умолчанию 2
+	Jitter   float64        // Доля паузы, на которую она случайно сокращается, от 0 до 1
+	Clock    Clock          // Таймеры, по умолчанию системные
+	Rand     func() float64 // Случайное число из [0, 1), по умолчанию math/rand
+}
+
+// Do - выполнение операции с повторами, пока она возвращает повторяемую ошибку (errx.IsRetryable).
+// Пауза из errx.RetryAfter важнее вычисленной, при отмене контекста (в том числе до первой попытки)
+// причиной становится ctx.Err().
+// Итоговая ошибка - ErrAttempts с последней ошибкой в причине и ошибками всех попыток в Debug,
+// вместе с детализацией и причинами, ключи "attempt N" дополнены нулями для сортировки.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	p = p.withDefaults()
+	dbg := make(errx.Debug, p.Attempts)
+	width := len(strconv.Itoa(p.Attempts))
+
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return ErrAttempts.WithReason(err).WithDebug(dbg)
+		}
+
+		err := fn(ctx)
+
+		if err == nil {
+			return nil
+		}
+
+		dbg[fmt.Sprintf("attempt %0*d", width, n)] = fmt.Sprintf("%v", err)
+
+		if n >= p.Attempts || !errx.IsRetryable(err) {
+			return ErrAttempts.WithReason(err).WithDebug(dbg)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ErrAttempts.WithReason(ctx.Err()).WithDebug(dbg)
+		case <-p.Clock.After(p.delay(n, err)):
+		}
+	}
+}
+
+// delay - пауза после попытки n
+func (p Policy) delay(n int, err error) time.Duration {
+	if d, ok := errx.RetryAfter(err); ok {
+		return d
+	}
+
+	d := float64(p.Delay)
+	for i := 1; i < n; i++ {
+		d *= p.Factor
+		if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+			break
+		}
+	}
+
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+
+	return time.Duration(d * (1 - p.Jitter*p.Rand()))
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.Attempts <= 0 {
+		p.Attempts = 3
+	}
+
+	if p.Delay <= 0 {
+		p.Delay = 100 * time.Millisecond
+	}
+
+	if p.Factor < 1 {
+		p.Factor = 2
+	}
+
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	} else if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+
+	if p.Clock == nil {
+		p.Clock = systemClock{}
+	}
+
+	if p.Rand == nil {
+		p.Rand = rand.Float64
+	}
+
+	return p
+}
+
+type systemClock struct{}
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,160 @@
+package retry_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/retry"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestRetry(t *testing.T) {
+	suite.Run(t, new(RetrySuite))
+}
+
+type RetrySuite struct {
+	suite.Suite
+}
+
+type fakeClock struct {
+	delays []time.Duration
+	cancel func()
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.delays = append(c.delays, d)
+
+	if c.cancel != nil {
+		c.cancel()
+		return nil
+	}
+
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+func (s *RetrySuite) policy(clk *fakeClock) retry.Policy {
+	return retry.Policy{
+		Attempts: 4,
+		Delay:    time.Second,
+		MaxDelay: 3 * time.Second,
+		Jitter:   0.5,
+		Clock:    clk,
+		Rand:     func() float64 { return 0.5 },
+	}
+}
+
+func (s *RetrySuite) TestSuccess() {
+	clk := new(fakeClock)
+	calls := 0
+
+	s.NoError(retry.Do(context.Background(), s.policy(clk), func(context.Context) error {
+		if calls++; calls < 3 {
+			return errx.ErrUnavailable.WithStack()
+		}
+		return nil
+	}))
+
+	s.Equal(3, calls)
+	s.Equal([]time.Duration{750 * time.Millisecond, 1500 * time.Millisecond}, clk.delays)
+}
+
+func (s *RetrySuite) TestExhausted() {
+	clk := new(fakeClock)
+	calls := 0
+
+	err := retry.Do(context.Background(), s.policy(clk), func(context.Context) error {
+		calls++
+		return errx.ErrUnavailable.WithDetail("call %d", calls)
+	})
+
+	s.Equal(4, calls)
+	s.True(errx.Is(err, retry.ErrAttempts))
+	s.True(errx.Is(err, errx.ErrUnavailable))
+	s.Equal([]time.Duration{750 * time.Millisecond, 1500 * time.Millisecond, 2250 * time.Millisecond}, clk.delays)
+
+	if v := err.(errx.Error).Export(); s.Len(v.Debug, 4) {
+		s.Equal(`"> 503 Service Unavailable (call 1)"`, v.Debug["attempt 1"])
+		s.Equal(`"> 503 Service Unavailable (call 4)"`, v.Debug["attempt 4"])
+		s.Equal("call 4", v.Next.Detail)
+	}
+}
+
+func (s *RetrySuite) TestNotRetryable() {
+	clk := new(fakeClock)
+	calls := 0
+
+	err := retry.Do(context.Background(), s.policy(clk), func(context.Context) error {
+		calls++
+		return io.EOF
+	})
+
+	s.Equal(1, calls)
+	s.Empty(clk.delays)
+	s.True(errx.Is(err, io.EOF))
+}
+
+func (s *RetrySuite) TestRetryAfter() {
+	clk := new(fakeClock)
+	calls := 0
+
+	err := retry.Do(context.Background(), s.policy(clk), func(context.Context) error {
+		if calls++; calls < 3 {
+			return errx.ErrBadRequest.WithRetryAfter(5 * time.Second)
+		}
+		return errx.ErrBadRequest.WithRetryAfter(time.Second).WithRetryable(false)
+	})
+
+	s.Equal(3, calls)
+	s.True(errx.Is(err, errx.ErrBadRequest))
+	s.Equal([]time.Duration{5 * time.Second, 5 * time.Second}, clk.delays)
+}
+
+func (s *RetrySuite) TestCancel() {
+	ctx, cancel := context.WithCancel(context.Background())
+	clk := &fakeClock{cancel: cancel}
+	calls := 0
+
+	err := retry.Do(ctx, s.policy(clk), func(context.Context) error {
+		calls++
+		return errx.ErrUnavailable.WithStack()
+	})
+
+	s.Equal(1, calls)
+	s.True(errx.Is(err, retry.ErrAttempts))
+	s.True(errx.Is(err, context.Canceled))
+}
+
+func (s *RetrySuite) TestCanceledBefore() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	err := retry.Do(ctx, s.policy(new(fakeClock)), func(context.Context) error {
+		calls++
+		return nil
+	})
+
+	s.Zero(calls)
+	s.True(errx.Is(err, retry.ErrAttempts))
+	s.True(errx.Is(err, context.Canceled))
+}
+
+func (s *RetrySuite) TestAttemptKeys() {
+	p := s.policy(new(fakeClock))
+	p.Attempts = 10
+
+	err := retry.Do(context.Background(), p, func(context.Context) error {
+		return errx.ErrUnavailable.WithReason(io.EOF)
+	})
+
+	if v := err.(errx.Error).Export(); s.Len(v.Debug, 10) {
+		s.Contains(v.Debug, "attempt 01")
+		s.Contains(v.Debug, "attempt 10")
+		s.Equal(`"> 503 Service Unavailable\n|-> EOF"`, v.Debug["attempt 02"])
+	}
+}
@@ -0,0 +1,98 @@
+package errx
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSourceSize - файлы больше этого размера для фрагментов не читаются
+const maxSourceSize = 4 << 20
+
+// sources - кэш строк исходников, файл перечитывается, если изменился
+var sources = struct {
+	sync.Mutex
+	files map[string]*sourceFile
+}{
+	files: make(map[string]*sourceFile),
+}
+
+type sourceFile struct {
+	mod   time.Time
+	size  int64
+	lines []string
+}
+
+// sourceLines - строки файла с диска, nil если файла нет или его не прочитать
+func sourceLines(name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil || fi.IsDir() || fi.Size() > maxSourceSize {
+		return nil
+	}
+
+	sources.Lock()
+	defer sources.Unlock()
+
+	if f, ok := sources.files[name]; ok && f.mod.Equal(fi.ModTime()) && f.size == fi.Size() {
+		return f.lines
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	scan := bufio.NewScanner(file)
+	scan.Buffer(nil, maxSourceSize)
+
+	for scan.Scan() {
+		lines = append(lines, strings.ReplaceAll(scan.Text(), "\t", "    "))
+	}
+
+	if scan.Err() != nil {
+		return nil
+	}
+
+	sources.files[name] = &sourceFile{mod: fi.ModTime(), size: fi.Size(), lines: lines}
+	return lines
+}
+
+// SourceLine - строка фрагмента исходника
+type SourceLine struct {
+	Number int
+	Text   string
+	Focus  bool // Строка самого кадра
+}
+
+// Snippet - строки исходника кадра, по n до и после, с диска и с кэшем по файлу.
+// Берется текущее содержимое файла: если его изменили после сборки, строки могут не совпасть с кадром.
+// Если файла нет или строка за его пределами, возвращает nil.
+func Snippet(f Frame, n int) []SourceLine {
+	lines := sourceLines(f.File)
+
+	if n < 0 || f.Line < 1 || f.Line > len(lines) {
+		return nil
+	}
+
+	from, to := f.Line-n, f.Line+n
+	if from < 1 {
+		from = 1
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+
+	res := make([]SourceLine, 0, to-from+1)
+	for i := from; i <= to; i++ {
+		res = append(res, SourceLine{Number: i, Text: lines[i-1], Focus: i == f.Line})
+	}
+	return res
+}
@@ -0,0 +1,180 @@
+package errx
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const stackTpl = "%s:%d -> %s()"
+
+var stackRx = regexp.MustCompile(`^(.*):(\d+) -> (.*)\(\)$`)
+
+// selfPkg - путь пакета errx, его кадры в стек не попадают никогда
+var selfPkg = reflect.TypeOf(Frame{}).PkgPath()
+
+// Frame - строка стека вызовов
+type Frame struct {
+	Func    string // Полное имя функции с путем пакета
+	File    string // Полный путь к файлу
+	Line    int
+	Module  string // Модуль сборки, если пакет к нему относится
+	Version string // Версия модуля
+	Path    string // Путь к файлу относительно корня модуля
+}
+
+// String - строка стека в формате "file.go:123 -> pkg.Func()"
+func (f Frame) String() string {
+	return fmt.Sprintf(stackTpl, path.Base(f.File), f.Line, path.Base(f.Func))
+}
+
+// Package - путь пакета функции
+func (f Frame) Package() string { return funcPackage(f.Func) }
+
+// Frames - стек внешнего слоя errx в цепочке, только для чтения
+func Frames(err error) []Frame {
+	var e *v1Error
+	if errors.As(err, &e) {
+		return e.frames()
+	}
+	return nil
+}
+
+// parseFrame - обратное преобразование строки стека, пути известны только базовые
+func parseFrame(s string) Frame {
+	m := stackRx.FindStringSubmatch(s)
+
+	if m == nil {
+		return Frame{Func: s}
+	}
+
+	line, _ := strconv.Atoi(m[2])
+	return Frame{Func: m[3], File: m[1], Line: line}
+}
+
+// callers - стек текущей горутины без кадров errx, skip как в runtime.Caller, limit > 0 - не больше limit кадров
+func callers(skip, limit int, keepRuntime bool) []Frame {
+	return framesOf(programCounters(skip+1, limit), limit, keepRuntime)
+}
+
+// programCounters - адреса стека, skip как в runtime.Caller.
+// С ограничением берется небольшой запас на кадры, которые потом будут отброшены.
+func programCounters(skip, limit int) []uintptr {
+	pcs := make([]uintptr, 16)
+
+	for {
+		if n := runtime.Callers(skip+1, pcs); n < len(pcs) || (limit > 0 && n >= limit+8) {
+			return pcs[:n]
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+}
+
+func framesOf(pcs []uintptr, limit int, keepRuntime bool) []Frame {
+	list := make([]Frame, 0, len(pcs))
+
+	if len(pcs) == 0 {
+		return list
+	}
+
+	iter := runtime.CallersFrames(pcs)
+
+	for {
+		f, more := iter.Next()
+
+		if !skipFrame(funcPackage(f.Function), keepRuntime) {
+			list = append(list, selfModules.Resolve(Frame{Func: f.Function, File: f.File, Line: f.Line}))
+		}
+
+		if !more || (limit > 0 && len(list) >= limit) {
+			break
+		}
+	}
+	return list
+}
+
+// skipFrame - кадры самого errx не нужны никогда, а кадры runtime - по настройке
+func skipFrame(pkg string, keepRuntime bool) bool {
+	return pkg == selfPkg || (!keepRuntime && pkg == "runtime")
+}
+
+func formatFrames(list []Frame) []string {
+	if list == nil {
+		return nil
+	}
+
+	res := make([]string, len(list))
+	for i := range list {
+		res[i] = list[i].String()
+	}
+	return res
+}
+
+// sharedFrames - сколько последних кадров слоя совпадает с кадрами внешнего слоя
+func sharedFrames(list, outer []Frame) int {
+	n := 0
+	for n < len(list) && n < len(outer) && list[len(list)-1-n] == outer[len(outer)-1-n] {
+		n++
+	}
+	return n
+}
+
+func parseFrames(list []string) []Frame {
+	res := make([]Frame, len(list))
+	for i := range list {
+		res[i] = parseFrame(list[i])
+	}
+	return res
+}
+
+// InModule - относится ли кадр к пакету одного из модулей, тесты пакета считаются его частью.
+// У распакованных кадров известно только имя пакета, полный путь восстанавливается по модулю и пути файла.
+// Если их нет, как после ParseText, имя пакета сравнивается с последним элементом пути модуля.
+func (f Frame) InModule(modules ...string) bool {
+	pkg := f.importPath()
+	if inModule(pkg, modules) {
+		return true
+	}
+
+	if f.Module != "" || strings.Contains(pkg, "/") {
+		return false
+	}
+
+	pkg = strings.TrimSuffix(pkg, "_test")
+	for _, mod := range modules {
+		if pkg == path.Base(mod) {
+			return true
+		}
+	}
+	return false
+}
+
+// importPath - полный путь пакета кадра
+func (f Frame) importPath() string {
+	pkg := f.Package()
+
+	if strings.Contains(pkg, "/") || f.Module == "" {
+		return pkg
+	}
+
+	if dir := path.Dir(f.Path); dir != "." {
+		return f.Module + "/" + dir
+	}
+	return f.Module
+}
+
+// inModule - относится ли пакет к одному из модулей, внешние тестовые пакеты тоже считаются
+func inModule(pkg string, modules []string) bool {
+	pkg = strings.TrimSuffix(pkg, "_test")
+	for _, mod := range modules {
+		if pkg == mod || strings.HasPrefix(pkg, mod+"/") {
+			return true
+		}
+	}
+	return false
+}
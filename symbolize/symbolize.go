@@ -0,0 +1,218 @@
+package symbolize
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/gosym"
+	"fmt"
+	"reflect"
+
+	fbs "github.com/google/flatbuffers/go"
+	"github.com/shestakovda/errx"
+)
+
+// ErrBuildID - стек собран другой сборкой, символизация дала бы неверные кадры
+var ErrBuildID = errx.Define("build ID mismatch")
+
+// Table - таблица символов бинарного файла
+type Table struct {
+	BuildID string // Go build ID файла
+	Module  string // Главный модуль и версия, как в errx.RawStack.Module
+
+	tab    *gosym.Table
+	mods   *errx.BuildModules
+	anchor uint64
+}
+
+// Open - чтение таблицы символов из ELF файла Go.
+// Встроенные (inlined) функции не разворачиваются, их строки относятся к вызывающей функции.
+func Open(path string) (*Table, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := new(Table)
+
+	if t.BuildID, err = errx.ReadBuildID(path); err != nil {
+		return nil, err
+	}
+
+	bi, err := buildinfo.ReadFile(path)
+	if err == nil && bi.Main.Path != "" {
+		t.Module = bi.Main.Path + "@" + bi.Main.Version
+	}
+	t.mods = errx.NewBuildModules(bi)
+
+	text := f.Section(".text")
+	if text == nil {
+		return nil, errx.ErrNotFound.WithDetail("no .text section in %s", path)
+	}
+
+	pcln, err := pclntab(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var symtab []byte
+	if sec := f.Section(".gosymtab"); sec != nil {
+		if symtab, err = sec.Data(); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.tab, err = gosym.NewTable(symtab, gosym.NewLineTable(pcln, text.Addr)); err != nil {
+		return nil, err
+	}
+
+	fn := t.tab.LookupFunc(errx.AnchorFunc)
+	if fn == nil {
+		return nil, errx.ErrNotFound.WithDetail("no %s in %s", errx.AnchorFunc, path)
+	}
+
+	t.anchor = fn.Entry
+	return t, nil
+}
+
+// errxPackage - кадры самого errx в стеке не нужны, адреса собираются без их отбора
+var errxPackage = reflect.TypeOf(errx.Frame{}).PkgPath()
+
+// Frames - кадры стека, адреса которого собраны процессом этой же сборки, без кадров errx и runtime
+func (t *Table) Frames(raw *errx.RawStack) ([]errx.Frame, error) {
+	if err := t.check(raw); err != nil {
+		return nil, err
+	}
+
+	// Сдвиг загрузки для PIE, для обычных сборок он нулевой
+	slide := raw.Base - t.anchor
+	list := make([]errx.Frame, 0, len(raw.PCs))
+
+	for _, pc := range raw.PCs {
+		// Адрес возврата указывает на следующую инструкцию после вызова
+		file, line, fn := t.tab.PCToLine(pc - slide - 1)
+
+		if fn == nil {
+			list = append(list, errx.Frame{Func: fmt.Sprintf("0x%x", pc)})
+			continue
+		}
+
+		if f := t.mods.Resolve(errx.Frame{Func: fn.Name, File: file, Line: line}); f.Package() != errxPackage && f.Package() != "runtime" {
+			list = append(list, f)
+		}
+	}
+
+	return list, nil
+}
+
+// View - заполнение Stack и Frames во всех слоях представления, где есть адреса
+func (t *Table) View(v *errx.View) error {
+	for ; v != nil; v = v.Next {
+		if v.Raw == nil {
+			continue
+		}
+
+		list, err := t.Frames(v.Raw)
+		if err != nil {
+			return err
+		}
+
+		if v.StackMode == errx.StackCaller && len(list) > 1 {
+			list = list[:1]
+		}
+
+		v.Frames = list
+		v.Stack = make([]string, len(list))
+		for i := range list {
+			v.Stack[i] = list[i].String()
+		}
+	}
+	return nil
+}
+
+// Unpack - распаковка ошибки errx.Pack с заменой адресов на обычный стек, на испорченном буфере - errx.ErrNotAcceptable
+func (t *Table) Unpack(buf []byte) (errx.Error, error) {
+	model, err := errx.ReadModel(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	for m := model; m != nil; m = m.Next {
+		if len(m.Pcs) == 0 {
+			continue
+		}
+
+		list, err := t.Frames(&errx.RawStack{
+			PCs:     m.Pcs,
+			BuildID: m.BuildId,
+			Module:  m.Module,
+			Base:    uint64(m.PcBase),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if errx.StackMode(m.StackMode) == errx.StackCaller && len(list) > 1 {
+			list = list[:1]
+		}
+
+		m.Stack = make([]string, len(list))
+		for i := range list {
+			m.Stack[i] = list[i].String()
+		}
+		m.Pcs, m.BuildId, m.Module, m.PcBase = nil, "", "", 0
+	}
+
+	b := fbs.NewBuilder(len(buf) * 2)
+	b.Finish(model.Pack(b))
+	return errx.Unpack(b.FinishedBytes()), nil
+}
+
+// check - совпадение сборки по build ID, а если его нет - по версии модуля
+func (t *Table) check(raw *errx.RawStack) error {
+	switch {
+	case raw.BuildID != "" && t.BuildID != "":
+		if raw.BuildID != t.BuildID {
+			return ErrBuildID.WithDetail("stack from %s, binary is %s", raw.BuildID, t.BuildID)
+		}
+	case raw.Module != "" && t.Module != "":
+		if raw.Module != t.Module {
+			return ErrBuildID.WithDetail("stack from %s, binary is %s", raw.Module, t.Module)
+		}
+	}
+	return nil
+}
+
+// pclntab - таблица строк из секции или, для внешней компоновки, по символам runtime.pclntab
+func pclntab(f *elf.File) ([]byte, error) {
+	if sec := f.Section(".gopclntab"); sec != nil {
+		return sec.Data()
+	}
+
+	syms, err := f.Symbols()
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to uint64
+	for _, s := range syms {
+		switch s.Name {
+		case "runtime.pclntab":
+			from = s.Value
+		case "runtime.epclntab":
+			to = s.Value
+		}
+	}
+
+	for _, sec := range f.Sections {
+		if from != 0 && from >= sec.Addr && to <= sec.Addr+sec.Size && to > from {
+			data, err := sec.Data()
+			if err != nil {
+				return nil, err
+			}
+			return data[from-sec.Addr : to-sec.Addr], nil
+		}
+	}
+
+	return nil, errx.ErrNotFound.WithDetail("no Go line table in ELF file")
+}
@@ -0,0 +1,82 @@
+package symbolize_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/shestakovda/errx"
+	"github.com/shestakovda/errx/symbolize"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSymbolize(t *testing.T) {
+	suite.Run(t, new(SymbolizeSuite))
+}
+
+type SymbolizeSuite struct {
+	suite.Suite
+	table *symbolize.Table
+}
+
+func (s *SymbolizeSuite) SetupSuite() {
+	exe, err := os.Executable()
+	s.Require().NoError(err)
+
+	s.table, err = symbolize.Open(exe)
+	s.Require().NoError(err)
+	s.Equal(errx.BuildID(), s.table.BuildID)
+
+	errx.SetStackOptions(errx.StackOptions{Raw: true})
+}
+
+func (s *SymbolizeSuite) TearDownSuite() {
+	errx.SetStackOptions(errx.StackOptions{})
+}
+
+func (s *SymbolizeSuite) TestPack() {
+	err := errx.New("outer").WithReason(errx.New("inner").WithStack())
+	v := errx.Unpack(err.Pack()).Export()
+
+	// В упакованной ошибке только адреса, их символизирует процесс той же сборки
+	s.Require().NotNil(v.Raw)
+	s.NotEmpty(v.Raw.PCs)
+	s.Equal(errx.BuildID(), v.Raw.BuildID)
+	s.Equal(errx.Frames(err), v.Frames)
+
+	// Теперь то же самое по файлу, как это делается на другой машине
+	forget(v)
+	s.Require().NoError(s.table.View(v))
+	s.Require().NotEmpty(v.Frames)
+	s.Equal("github.com/shestakovda/errx/symbolize_test.(*SymbolizeSuite).TestPack", v.Frames[0].Func)
+	s.Equal("symbolize_test.go", v.Stack[0][:len("symbolize_test.go")])
+	s.Require().NotNil(v.Next)
+	s.Equal(v.Frames[0].Func, v.Next.Frames[0].Func)
+
+	s.Equal(errx.Frames(err), v.Frames)
+
+	res, e := s.table.Unpack(err.Pack())
+	s.Require().NoError(e)
+	s.Nil(res.Export().Raw)
+	s.Equal(v.Stack, res.Export().Stack)
+
+	// Чужие данные вместо ошибки не роняют процесс
+	_, e = s.table.Unpack([]byte("not a packed error"))
+	s.True(errx.Is(e, errx.ErrNotAcceptable))
+}
+
+func (s *SymbolizeSuite) TestMismatch() {
+	v := errx.New("some").WithStack().Export()
+	v.Raw.BuildID = "other"
+	forget(v)
+
+	err := s.table.View(v)
+	s.True(errx.Is(err, symbolize.ErrBuildID))
+	s.Empty(v.Stack)
+}
+
+// forget - стек без символизации, как он виден процессу другой сборки
+func forget(v *errx.View) {
+	for ; v != nil; v = v.Next {
+		v.Stack, v.Frames = nil, nil
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux && !darwin
+
+package errx
+
+// fdWidth - ширина терминала на этой платформе не определяется
+func fdWidth(fd uintptr) int { return 0 }
@@ -0,0 +1,20 @@
+//go:build linux || darwin
+
+package errx
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// fdWidth - ширина терминала по дескриптору, 0 если это не терминал
+func fdWidth(fd uintptr) int {
+	var ws struct {
+		Row, Col, X, Y uint16
+	}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
@@ -0,0 +1,13 @@
+package app
+
+// Service - пример для фрагментов исходника в Render
+type Service struct {
+	users map[int]string
+}
+
+func (s *Service) Find(id int) (string, error) {
+	if name, ok := s.users[id]; ok {
+		return name, nil
+	}
+	return "", ErrNotFound.WithDetail("id %d", id)
+}
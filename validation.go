@@ -0,0 +1,195 @@
+package errx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// violationMark - начало строки нарушения в выводе %v: "|   ! /items/2/qty: must be > 0 [min]"
+const violationMark = "! "
+
+// Violation - нарушение правила проверки в одном поле запроса
+type Violation struct {
+	Path    string            // JSON Pointer до поля, например "/items/2/qty", пустой для всего документа
+	Code    string            // Машинный код правила, например "min" или "invalid_format"
+	Message string            // Ключ каталога или готовый текст, параметры подставляются вместо {name}
+	Params  map[string]string // Параметры сообщения
+	Detail  string            // Готовый текст без каталога и подстановки параметров, важнее Message
+}
+
+// Text - готовый текст Detail, иначе сообщение из каталога в первой подходящей локали,
+// а если там нет ключа - сам текст Message с параметрами
+func (v Violation) Text(locales ...string) string {
+	if v.Detail != "" {
+		return v.Detail
+	}
+
+	if text, ok := localize(v.Message, v.Params, locales); ok {
+		return text
+	}
+	return substitute(v.Message, v.Params)
+}
+
+// Field - путь в привычной записи "items[2].qty", пустой для всего документа
+func (v Violation) Field() string {
+	if v.Path == "" {
+		return ""
+	}
+
+	var buf strings.Builder
+	for _, s := range strings.Split(strings.TrimPrefix(v.Path, "/"), "/") {
+		s = strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+
+		if _, err := strconv.Atoi(s); err == nil {
+			fmt.Fprintf(&buf, "[%s]", s)
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteByte('.')
+		}
+		buf.WriteString(s)
+	}
+	return buf.String()
+}
+
+// String - поле и сообщение в локали по умолчанию: "items[2].qty: must be > 0"
+func (v Violation) String() string {
+	if field := v.Field(); field != "" {
+		return field + ": " + v.Text()
+	}
+	return v.Text()
+}
+
+// Pointer - JSON Pointer из имен полей и индексов, спецсимволы "~" и "/" экранируются
+func Pointer(segments ...interface{}) string {
+	var buf strings.Builder
+	for i := range segments {
+		buf.WriteByte('/')
+		buf.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(fmt.Sprint(segments[i])))
+	}
+	return buf.String()
+}
+
+// pointer - путь JSON Pointer с "/" в начале, пустой остается пустым
+func pointer(path string) string {
+	if path == "" || strings.HasPrefix(path, "/") {
+		return path
+	}
+	return "/" + path
+}
+
+// ValidationError - накопитель нарушений при проверке запроса.
+// Вложенные структуры и списки проверяются отдельно и добавляются через Merge с префиксом пути.
+// Готовая ошибка - Err, это ErrUnprocessable с нарушениями.
+type ValidationError struct {
+	list []Violation
+}
+
+// NewValidationError - пустой накопитель нарушений
+func NewValidationError() *ValidationError {
+	return new(ValidationError)
+}
+
+// Add - нарушение по пути JSON Pointer, для всего документа путь пустой, недостающий "/" в начале добавляется
+func (v *ValidationError) Add(path, code, message string, params P) *ValidationError {
+	v.list = append(v.list, Violation{Path: pointer(path), Code: code, Message: message, Params: stringParams(params)})
+	return v
+}
+
+// Merge - нарушения вложенной проверки с путями относительно prefix.
+// Ошибка без нарушений добавляется одним нарушением по самому prefix с кодом "invalid".
+// Ее текст может быть внутренним, поэтому сообщение берется из публичной проекции:
+// ключ WithMessage, иначе детализация публичного слоя как готовый текст, а без них - "invalid".
+func (v *ValidationError) Merge(prefix string, err error) *ValidationError {
+	if err == nil {
+		return v
+	}
+
+	prefix = pointer(prefix)
+
+	list := Violations(err)
+	if list == nil {
+		pub := PublicError(err).(*v1Error)
+
+		switch {
+		case pub.msg != "":
+			v.list = append(v.list, Violation{Path: prefix, Code: "invalid", Message: pub.msg, Params: pub.params})
+		case pub.detail != "":
+			v.list = append(v.list, Violation{Path: prefix, Code: "invalid", Detail: pub.detail})
+		default:
+			v.list = append(v.list, Violation{Path: prefix, Code: "invalid", Message: "invalid"})
+		}
+		return v
+	}
+
+	for i := range list {
+		list[i].Path = prefix + list[i].Path
+		v.list = append(v.list, list[i])
+	}
+	return v
+}
+
+// Len - количество нарушений
+func (v *ValidationError) Len() int { return len(v.list) }
+
+// Violations - копия списка нарушений в порядке добавления
+func (v *ValidationError) Violations() []Violation {
+	return append([]Violation(nil), v.list...)
+}
+
+// Err - ErrUnprocessable с собранными нарушениями, nil если их нет
+func (v *ValidationError) Err() Error {
+	if len(v.list) == 0 {
+		return nil
+	}
+
+	err := ErrUnprocessable.(*v1Error).withStack()
+	err.violations = v.Violations()
+	return ErrUnprocessable.(*v1Error).created(err)
+}
+
+func (e *v1Error) WithViolations(list ...Violation) Error {
+	err := e.withStack()
+	err.violations = append(append([]Violation(nil), e.violations...), list...)
+	return e.created(err)
+}
+
+// Violations - нарушения ближайшего слоя цепочки, где они есть, nil если их нет нигде
+func Violations(err error) []Violation {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(*v1Error); ok && len(e.violations) > 0 {
+			return append([]Violation(nil), e.violations...)
+		}
+	}
+	return nil
+}
+
+// localized - копии нарушений с сообщениями, уже полученными в первой подходящей локали
+func localized(list []Violation, locales []string) []Violation {
+	if list == nil {
+		return nil
+	}
+
+	res := make([]Violation, len(list))
+	for i := range list {
+		res[i] = list[i]
+		res[i].Message = list[i].Text(locales...)
+	}
+	return res
+}
+
+// stringParams - параметры сообщения в текстовом виде
+func stringParams(params P) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+
+	res := make(map[string]string, len(params))
+	for name := range params {
+		res[name] = fmt.Sprint(params[name])
+	}
+	return res
+}